@@ -32,4 +32,9 @@ var (
 	ColorItemPerkDescription = color.NRGBA{200, 200, 200, 200}
 	ColorItemUses            = color.NRGBA{255, 255, 255, 200}
 	ColorItemLevel           = color.NRGBA{200, 200, 255, 200}
+	ColorItemSet             = color.NRGBA{100, 200, 200, 200}
+	ColorItemUnique          = color.NRGBA{255, 160, 40, 255}
+	ColorItemCompare         = color.NRGBA{220, 220, 160, 200}
+	ColorStatUp              = color.NRGBA{75, 250, 75, 255}
+	ColorStatDown            = color.NRGBA{250, 75, 75, 255}
 )
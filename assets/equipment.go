@@ -19,6 +19,11 @@ type EquipmentAsset struct {
 	Professions []string       `yaml:"professions,omitempty"`
 	Stats       map[string]int `yaml:"stats,omitempty"`
 	Perk        string         `yaml:"perk,omitempty"`
+	Stack       string         `yaml:"stack,omitempty"`      // Stack to draw with, defaults to BaseName
+	Slot        string         `yaml:"slot,omitempty"`       // Slot to wear it in (head, body, mainhand, offhand, hand, accessory), defaults by Type
+	TwoHanded   bool           `yaml:"twoHanded,omitempty"`  // Takes up the off hand as well as the main hand
+	Sheet       string         `yaml:"sheet,omitempty"`      // Sheet to draw from, defaults to Type
+	Consumable  string         `yaml:"consumable,omitempty"` // What a consumable does when used (potion, antidote, smokebomb, return)
 }
 
 // Load all equipment listed in the 'equipment/equipmentList.txt' file
@@ -0,0 +1,52 @@
+package assets
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+var equipmentSets []*EquipmentSetAsset
+
+// EquipmentSetAsset is a set of equipment that gives bonuses when worn together.
+// Members are the base names of the equipment in the set.
+type EquipmentSetAsset struct {
+	Name        string                   `yaml:"name"`
+	Description string                   `yaml:"description"`
+	Members     []string                 `yaml:"members"`
+	Bonuses     []EquipmentSetBonusAsset `yaml:"bonuses"`
+}
+
+// EquipmentSetBonusAsset is a bonus given once enough pieces of a set are equipped.
+type EquipmentSetBonusAsset struct {
+	Pieces      int            `yaml:"pieces"`
+	Stats       map[string]int `yaml:"stats,omitempty"`
+	Perk        string         `yaml:"perk,omitempty"`
+	PerkStat    string         `yaml:"perkStat,omitempty"` // Stat boosted if the perk is a stat boost
+	PerkQuality int            `yaml:"perkQuality,omitempty"`
+}
+
+// Load all equipment sets from 'equipment/sets.yaml', must be called after LoadEquipment
+func LoadEquipmentSets() {
+	bytes, err := FS.ReadFile("equipment/sets.yaml")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := yaml.Unmarshal(bytes, &equipmentSets); err != nil {
+		panic("Error unmarshalling equipment sets yaml: " + err.Error())
+	}
+
+	// Make sure every member is real equipment
+	for _, s := range equipmentSets {
+		for _, m := range s.Members {
+			if _, err := GetEquipment(m); err != nil {
+				panic(fmt.Sprintf("Equipment set %s has unknown member: %s", s.Name, m))
+			}
+		}
+	}
+}
+
+func GetEquipmentSets() []*EquipmentSetAsset {
+	return equipmentSets
+}
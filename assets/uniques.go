@@ -0,0 +1,46 @@
+package assets
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v2"
+)
+
+var uniqueEquipment []*UniqueEquipmentAsset
+
+// UniqueEquipmentAsset is a hand-made legendary built on top of regular equipment.
+type UniqueEquipmentAsset struct {
+	Name        string         `yaml:"name"`
+	Base        string         `yaml:"base"` // Equipment it's built on, for its type, slots and professions
+	Description string         `yaml:"description"`
+	Stats       map[string]int `yaml:"stats,omitempty"` // Stats per level, replacing those of the base
+	Perk        string         `yaml:"perk"`            // Signature perk, one that is never rolled
+	PerkQuality int            `yaml:"perkQuality,omitempty"`
+	Stack       string         `yaml:"stack,omitempty"`    // Stack to draw with, defaults to the base's
+	Tint        string         `yaml:"tint,omitempty"`     // Color to draw with instead of the legendary one, such as "#ffd700"
+	MinStory    int            `yaml:"minStory,omitempty"` // Shallowest story it can be found in outside of boss fights
+	Boss        bool           `yaml:"boss,omitempty"`     // Only dropped by the boss whose loot table names it
+}
+
+// Load all unique equipment from 'equipment/uniques.yaml', must be called after LoadEquipment
+func LoadUniqueEquipment() {
+	bytes, err := FS.ReadFile("equipment/uniques.yaml")
+	if err != nil {
+		panic(err)
+	}
+
+	if err := yaml.Unmarshal(bytes, &uniqueEquipment); err != nil {
+		panic("Error unmarshalling unique equipment yaml: " + err.Error())
+	}
+
+	// Make sure every unique is built on real equipment
+	for _, u := range uniqueEquipment {
+		if _, err := GetEquipment(u.Base); err != nil {
+			panic(fmt.Sprintf("Unique equipment %s has unknown base: %s", u.Name, u.Base))
+		}
+	}
+}
+
+func GetUniqueEquipment() []*UniqueEquipmentAsset {
+	return uniqueEquipment
+}
@@ -53,6 +53,18 @@ func (t TowerLeaveActivity) Cb() func(success bool) {
 	return t.cb
 }
 
+type TowerFleeActivity struct {
+	dude *Dude
+	cb   func(success bool)
+}
+
+func (t TowerFleeActivity) Apply() {
+}
+
+func (t TowerFleeActivity) Cb() func(success bool) {
+	return t.cb
+}
+
 type TowerCompleteActivity struct {
 	dude *Dude
 	cb   func(success bool)
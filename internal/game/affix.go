@@ -0,0 +1,152 @@
+package game
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// EnemyRank is how strong a variant of an enemy is.
+type EnemyRank int
+
+const (
+	EnemyRankNormal EnemyRank = iota
+	EnemyRankElite
+	EnemyRankChampion
+)
+
+func (r EnemyRank) String() string {
+	switch r {
+	case EnemyRankElite:
+		return "Elite"
+	case EnemyRankChampion:
+		return "Champion"
+	default:
+		return ""
+	}
+}
+
+// Affixes returns how many affixes an enemy of the rank carries.
+func (r EnemyRank) Affixes() int {
+	switch r {
+	case EnemyRankElite:
+		return 1
+	case EnemyRankChampion:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// RewardMultiplier returns how much gold and xp are scaled by for the rank.
+func (r EnemyRank) RewardMultiplier() float64 {
+	switch r {
+	case EnemyRankElite:
+		return 2
+	case EnemyRankChampion:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// StatMultiplier returns how much strength and hp are scaled by for the rank.
+func (r EnemyRank) StatMultiplier() float64 {
+	switch r {
+	case EnemyRankElite:
+		return 1.5
+	case EnemyRankChampion:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// RollEnemyRank rolls the rank of a newly spawned enemy, deeper stories spawn more elites.
+func RollEnemyRank(storyLevel int) EnemyRank {
+	roll := rand.Float64()
+	championChance := math.Min(0.02*float64(storyLevel), 0.2)
+	eliteChance := math.Min(0.05*float64(storyLevel), 0.4)
+	if roll < championChance {
+		return EnemyRankChampion
+	} else if roll < championChance+eliteChance {
+		return EnemyRankElite
+	}
+	return EnemyRankNormal
+}
+
+// EnemyAffix is a modifier carried by elite and champion enemies.
+type EnemyAffix int
+
+const (
+	// Vampiric heals for part of the damage it deals.
+	EnemyAffixVampiric EnemyAffix = iota
+	// Armored has increased defense.
+	EnemyAffixArmored
+	// Swift can evade attacks.
+	EnemyAffixSwift
+	// Explosive damages its killer on death.
+	EnemyAffixExplosive
+	// GoldHoarding drops much more gold.
+	EnemyAffixGoldHoarding
+	// Marker for the end... allows for iteration
+	EnemyAffixEnd
+)
+
+func (a EnemyAffix) String() string {
+	switch a {
+	case EnemyAffixVampiric:
+		return "Vampiric"
+	case EnemyAffixArmored:
+		return "Armored"
+	case EnemyAffixSwift:
+		return "Swift"
+	case EnemyAffixExplosive:
+		return "Explosive"
+	case EnemyAffixGoldHoarding:
+		return "Gold-Hoarding"
+	default:
+		return "Unknown"
+	}
+}
+
+// Color returns the tint applied to the enemy's stack for the affix.
+func (a EnemyAffix) Color() ebiten.ColorScale {
+	cs := ebiten.ColorScale{}
+	switch a {
+	case EnemyAffixVampiric:
+		cs.Scale(1, 0.4, 0.4, 1)
+	case EnemyAffixArmored:
+		cs.Scale(0.6, 0.7, 1, 1)
+	case EnemyAffixSwift:
+		cs.Scale(0.6, 1, 0.6, 1)
+	case EnemyAffixExplosive:
+		cs.Scale(1, 0.6, 0.2, 1)
+	case EnemyAffixGoldHoarding:
+		cs.Scale(1, 0.85, 0.2, 1)
+	}
+	return cs
+}
+
+// Apply modifies the stats for the affix.
+func (a EnemyAffix) Apply(stats *Stats) {
+	switch a {
+	case EnemyAffixArmored:
+		stats.defense = int(float64(stats.defense) * 1.5)
+	case EnemyAffixSwift:
+		stats.agility += 20
+	}
+}
+
+// RollEnemyAffixes returns count unique random affixes.
+func RollEnemyAffixes(count int) []EnemyAffix {
+	var affixes []EnemyAffix
+	for _, i := range rand.Perm(int(EnemyAffixEnd)) {
+		if len(affixes) >= count {
+			break
+		}
+		affixes = append(affixes, EnemyAffix(i))
+	}
+	return affixes
+}
@@ -0,0 +1,162 @@
+package game
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+// CombatAction is the kind of thing that happened in a combat entry.
+type CombatAction string
+
+const (
+	CombatActionHit   CombatAction = "hit"
+	CombatActionCrit  CombatAction = "crit"
+	CombatActionMiss  CombatAction = "miss"
+	CombatActionDodge CombatAction = "dodge"
+	CombatActionHeal  CombatAction = "heal"
+	CombatActionLoot  CombatAction = "loot"
+	CombatActionDeath CombatAction = "death"
+	CombatActionUse   CombatAction = "use"
+)
+
+// CombatEntry is a structured record of a single combat happening.
+type CombatEntry struct {
+	Tick   int          `json:"tick"`
+	Story  int          `json:"story"`
+	Room   int          `json:"room"`
+	Actor  string       `json:"actor"`
+	Target string       `json:"target"`
+	Action CombatAction `json:"action"`
+	Amount int          `json:"amount"`
+	Perk   string       `json:"perk,omitempty"`
+	dude   *Dude        // The dude the entry is about, used for filtering.
+}
+
+// CombatLog holds every combat entry of the current run.
+type CombatLog struct {
+	ticks   int
+	source  string // The perk or skill currently being activated, if any.
+	entries []*CombatEntry
+}
+
+var combatLog CombatLog
+
+// ResetCombatLog clears the combat log, used when a new run begins.
+func ResetCombatLog() {
+	combatLog = CombatLog{}
+}
+
+// TickCombatLog advances the combat log's tick counter.
+func TickCombatLog() {
+	combatLog.ticks++
+}
+
+// GetCombatEntries returns all combat entries of the current run.
+func GetCombatEntries() []*CombatEntry {
+	return combatLog.entries
+}
+
+// NewCombatEntry creates an entry for the given dude using its current story and room.
+func NewCombatEntry(d *Dude, action CombatAction, target string, amount int) *CombatEntry {
+	entry := &CombatEntry{
+		Tick:   combatLog.ticks,
+		Story:  -1,
+		Room:   -1,
+		Action: action,
+		Target: target,
+		Amount: amount,
+		dude:   d,
+	}
+	if d != nil {
+		entry.Actor = d.name
+		if d.story != nil {
+			entry.Story = d.story.level
+		}
+		if d.room != nil {
+			entry.Room = d.room.index
+		}
+	}
+	entry.Perk = combatLog.source
+	return entry
+}
+
+// NewCombatEntryAgainst creates an entry where the given dude is the target of the source.
+func NewCombatEntryAgainst(d *Dude, action CombatAction, source string, amount int) *CombatEntry {
+	entry := NewCombatEntry(d, action, "", amount)
+	entry.Actor, entry.Target = source, entry.Actor
+	return entry
+}
+
+// AddCombatEntry records the entry in the combat log without adding a message.
+func AddCombatEntry(entry *CombatEntry) {
+	combatLog.entries = append(combatLog.entries, entry)
+}
+
+// AddCombatMessage records the entry in the combat log and adds a message for it.
+func AddCombatMessage(kind MessageKind, entry *CombatEntry, text string) *Message {
+	AddCombatEntry(entry)
+	m := AddMessage(kind, text)
+	m.entry = entry
+	return m
+}
+
+// ExportCombatLog writes the combat log as CSV and JSON files to the working directory.
+func ExportCombatLog() (string, error) {
+	base := fmt.Sprintf("combatlog-%s", time.Now().Format("20060102-150405"))
+
+	jf, err := os.Create(base + ".json")
+	if err != nil {
+		return "", err
+	}
+	enc := json.NewEncoder(jf)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(combatLog.entries); err != nil {
+		jf.Close()
+		return "", err
+	}
+	if err := jf.Close(); err != nil {
+		return "", err
+	}
+
+	cf, err := os.Create(base + ".csv")
+	if err != nil {
+		return "", err
+	}
+	if err := writeCombatLogCSV(cf); err != nil {
+		cf.Close()
+		return "", err
+	}
+	if err := cf.Close(); err != nil {
+		return "", err
+	}
+
+	return base, nil
+}
+
+// writeCombatLogCSV writes the combat log entries as CSV rows.
+func writeCombatLogCSV(f *os.File) error {
+	w := csv.NewWriter(f)
+	if err := w.Write([]string{"tick", "story", "room", "actor", "target", "action", "amount", "perk"}); err != nil {
+		return err
+	}
+	for _, e := range combatLog.entries {
+		if err := w.Write([]string{
+			strconv.Itoa(e.Tick),
+			strconv.Itoa(e.Story),
+			strconv.Itoa(e.Room),
+			e.Actor,
+			e.Target,
+			string(e.Action),
+			strconv.Itoa(e.Amount),
+			e.Perk,
+		}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
@@ -0,0 +1,243 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompareBestCount is how many dudes are suggested as the best fit for an item.
+const CompareBestCount = 3
+
+// EquipmentComparison is how a dude's stats would change by swapping in a piece of equipment.
+type EquipmentComparison struct {
+	dude      *Dude
+	equipment *Equipment
+	displaced []*Equipment // What would come off to make room.
+	before    *Stats
+	after     *Stats
+	canEquip  bool
+}
+
+// CompareEquipment compares the dude's current stats against those with the equipment swapped in.
+func CompareEquipment(d *Dude, eq *Equipment) EquipmentComparison {
+	return EquipmentComparison{
+		dude:      d,
+		equipment: eq,
+		displaced: d.Displaced(eq, d.SlotFor(eq)),
+		before:    d.GetCalculatedStats(),
+		after:     d.StatsWith(eq),
+		canEquip:  d.CanEquip(eq),
+	}
+}
+
+// Delta returns the change in each stat.
+func (c EquipmentComparison) Delta() *Stats {
+	return &Stats{
+		totalHp:    c.after.totalHp - c.before.totalHp,
+		strength:   c.after.strength - c.before.strength,
+		wisdom:     c.after.wisdom - c.before.wisdom,
+		defense:    c.after.defense - c.before.defense,
+		agility:    c.after.agility - c.before.agility,
+		confidence: c.after.confidence - c.before.confidence,
+		luck:       c.after.luck - c.before.luck,
+	}
+}
+
+// Gain weighs the stat changes into a single number, positive if the swap is an upgrade.
+func (c EquipmentComparison) Gain() int {
+	return statScore(c.Delta())
+}
+
+// PerkString describes how the perks would change, or returns an empty string if neither item has one.
+func (c EquipmentComparison) PerkString() string {
+	var old []string
+	for _, e := range c.displaced {
+		if e.HasPerks() {
+			old = append(old, e.PerkNames())
+		}
+	}
+	if len(old) == 0 && !c.equipment.HasPerks() {
+		return ""
+	}
+	from := "no perk"
+	if len(old) > 0 {
+		from = strings.Join(old, ", ")
+	}
+	to := "no perk"
+	if c.equipment.HasPerks() {
+		to = c.equipment.PerkNames()
+	}
+	return fmt.Sprintf("%s -> %s", from, to)
+}
+
+// String describes the comparison for the details panel.
+func (c EquipmentComparison) String() string {
+	if !c.canEquip {
+		profession := c.dude.Profession()
+		return fmt.Sprintf("%s can't equip this as a %s", c.dude.Name(), profession.String())
+	}
+	var lines []string
+	if len(c.displaced) == 0 {
+		lines = append(lines, fmt.Sprintf("%s has a free slot", c.dude.Name()))
+	} else {
+		var names []string
+		for _, e := range c.displaced {
+			names = append(names, e.Name())
+		}
+		lines = append(lines, fmt.Sprintf("vs %s's %s", c.dude.Name(), strings.Join(names, ", ")))
+	}
+	if perks := c.PerkString(); perks != "" {
+		lines = append(lines, perks)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// statScore weighs stats into a single number, health and confidence come in larger amounts so they count for less.
+func statScore(s *Stats) int {
+	return s.totalHp/5 + s.strength + s.wisdom + s.defense + s.agility + s.confidence/5 + s.luck
+}
+
+// EquippedWith returns a copy of the dude's equipped items with the equipment swapped in.
+func (d *Dude) EquippedWith(eq *Equipment) map[EquipmentSlot]*Equipment {
+	slot := d.SlotFor(eq)
+	displaced := d.Displaced(eq, slot)
+	equipped := make(map[EquipmentSlot]*Equipment, len(d.equipped))
+	for s, e := range d.equipped {
+		equipped[s] = e
+	}
+	for s, e := range equipped {
+		for _, off := range displaced {
+			if e == off {
+				delete(equipped, s)
+			}
+		}
+	}
+	equipped[slot] = eq
+	return equipped
+}
+
+// StatsWith returns the dude's calculated stats as if the equipment were swapped in.
+func (d *Dude) StatsWith(eq *Equipment) *Stats {
+	equipped := d.equipped
+	d.equipped = d.EquippedWith(eq)
+	stats := d.GetCalculatedStats()
+	d.equipped = equipped
+	return stats
+}
+
+// BestDudesFor returns the dudes that would gain the most from the equipment, best first.
+func BestDudesFor(eq *Equipment, dudes []*Dude) []EquipmentComparison {
+	var best []EquipmentComparison
+	if eq.IsConsumable() {
+		return nil
+	}
+	for _, d := range dudes {
+		if d.IsDead() || !d.CanEquip(eq) {
+			continue
+		}
+		if _, worn := d.SlotOf(eq); worn {
+			continue
+		}
+		if c := CompareEquipment(d, eq); c.Gain() > 0 {
+			best = append(best, c)
+		}
+	}
+	sort.SliceStable(best, func(i, j int) bool {
+		return best[i].Gain() > best[j].Gain()
+	})
+	if len(best) > CompareBestCount {
+		best = best[:CompareBestCount]
+	}
+	return best
+}
+
+// BestDudesString describes which dudes would benefit most from the equipment.
+func BestDudesString(eq *Equipment, dudes []*Dude) string {
+	best := BestDudesFor(eq, dudes)
+	if len(best) == 0 {
+		return "No dude would be better off with this"
+	}
+	var names []string
+	for _, c := range best {
+		names = append(names, fmt.Sprintf("%s %+d", c.dude.Name(), c.Gain()))
+	}
+	return "Best for " + strings.Join(names, ", ")
+}
+
+// AutoEquipSwap is an item auto-equip would put on a dude, and what would come off for it.
+type AutoEquipSwap struct {
+	dude      *Dude
+	equipment *Equipment
+	displaced []*Equipment
+}
+
+func (s AutoEquipSwap) String() string {
+	if s.equipment.IsConsumable() {
+		return fmt.Sprintf("%s packs %s", s.dude.Name(), s.equipment.Name())
+	}
+	if len(s.displaced) == 0 {
+		return fmt.Sprintf("%s equips %s", s.dude.Name(), s.equipment.Name())
+	}
+	var names []string
+	for _, e := range s.displaced {
+		names = append(names, e.Name())
+	}
+	return fmt.Sprintf("%s equips %s over %s", s.dude.Name(), s.equipment.Name(), strings.Join(names, ", "))
+}
+
+// PlanAutoEquip works out the swaps auto-equip would make under the policy, giving each dude in order first pick of the equipment. Nothing is equipped.
+func PlanAutoEquip(dudes []*Dude, equipment []*Equipment, policy *LootPolicy) []AutoEquipSwap {
+	var swaps []AutoEquipSwap
+	pool := make([]*Equipment, len(equipment))
+	copy(pool, equipment)
+
+	equipped := make(map[*Dude]map[EquipmentSlot]*Equipment, len(dudes))
+	belts := make(map[*Dude][]*Equipment, len(dudes))
+	for _, d := range dudes {
+		equipped[d] = d.equipped
+		belts[d] = d.belt
+	}
+	defer func() {
+		for d, e := range equipped {
+			d.equipped = e
+			d.belt = belts[d]
+		}
+	}()
+
+	// Spreading hands out one item per dude each round, going around until nobody wants anything. Displaced items can be picked up again, so cap the rounds.
+	picks := -1
+	rounds := 1
+	if policy.Spread {
+		picks = 1
+		rounds = len(equipment)
+	}
+	for round := 0; round < rounds; round++ {
+		planned := len(swaps)
+		for _, d := range dudes {
+			var rest []*Equipment
+			taken := 0
+			for _, e := range pool {
+				if taken == picks || !policy.ShouldEquip(d, e) {
+					rest = append(rest, e)
+					continue
+				}
+				displaced := d.Displaced(e, d.SlotFor(e))
+				if e.IsConsumable() {
+					// Copy so the real belt is left alone.
+					d.belt = append(d.belt[:len(d.belt):len(d.belt)], e)
+				} else {
+					d.equipped = d.EquippedWith(e)
+				}
+				swaps = append(swaps, AutoEquipSwap{dude: d, equipment: e, displaced: displaced})
+				rest = append(rest, displaced...)
+				taken++
+			}
+			pool = rest
+		}
+		if len(swaps) == planned {
+			break
+		}
+	}
+	return swaps
+}
@@ -0,0 +1,246 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+const (
+	BeltSize             = 3    // Consumables a dude can carry.
+	ConsumableLootChance = 0.25 // Chance room loot is a consumable rather than equipment.
+	ConsumableHeal       = 0.4  // Fraction of max hp a healing potion restores, before wisdom scaling.
+	ShopConsumableCount  = 2    // Consumables for sale each build phase, on top of the equipment.
+)
+
+// UseThresholds are the hp fractions a dude can be set to use consumables at.
+var UseThresholds = []float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6, 0.7}
+
+// DefaultUseThreshold is the hp fraction dudes start out using consumables at.
+const DefaultUseThreshold = 0.3
+
+// ConsumableKind is what a consumable does when used.
+type ConsumableKind string
+
+const (
+	ConsumablePotion    ConsumableKind = "potion"
+	ConsumableAntidote  ConsumableKind = "antidote"
+	ConsumableSmokeBomb ConsumableKind = "smokebomb"
+	ConsumableReturn    ConsumableKind = "return"
+)
+
+// String returns a short name for the kind, for tight spots like the belt.
+func (ck ConsumableKind) String() string {
+	switch ck {
+	case ConsumablePotion:
+		return "Potion"
+	case ConsumableAntidote:
+		return "Antidote"
+	case ConsumableSmokeBomb:
+		return "Smoke Bomb"
+	case ConsumableReturn:
+		return "Scroll"
+	default:
+		return "Unknown"
+	}
+}
+
+// Description describes what the consumable does and when it gets used. Smoke bombs and scrolls are only reached for once the potions run out.
+func (ck ConsumableKind) Description() string {
+	switch ck {
+	case ConsumablePotion:
+		return fmt.Sprintf("Heals %d%% hp below the use threshold", int(ConsumableHeal*100))
+	case ConsumableAntidote:
+		return "Wards off a curse"
+	case ConsumableSmokeBomb:
+		return "Escapes a fight below the use threshold"
+	case ConsumableReturn:
+		return "Returns home below half the use threshold"
+	default:
+		return "Does nothing"
+	}
+}
+
+// Value returns what the consumable sells for.
+func (ck ConsumableKind) Value() int {
+	switch ck {
+	case ConsumablePotion:
+		return 20
+	case ConsumableAntidote:
+		return 15
+	case ConsumableSmokeBomb:
+		return 25
+	case ConsumableReturn:
+		return 40
+	default:
+		return 0
+	}
+}
+
+// IsConsumable returns if the equipment is a consumable carried on the belt rather than worn.
+func (e *Equipment) IsConsumable() bool {
+	return e.equipmentType == EquipmentTypeConsumable
+}
+
+// Consumable returns what the consumable does, or an empty kind for other equipment.
+func (e *Equipment) Consumable() ConsumableKind {
+	return e.consumable
+}
+
+// GetRandomConsumable creates a random consumable.
+func GetRandomConsumable() *Equipment {
+	names := GetEquipmentNamesWithTypes([]EquipmentType{EquipmentTypeConsumable})
+	if len(names) == 0 {
+		return nil
+	}
+	return NewEquipment(*names[rand.Intn(len(names))], 0, EquipmentQualityCommon, nil)
+}
+
+// Belt returns the consumables the dude is carrying.
+func (d *Dude) Belt() []*Equipment {
+	return d.belt
+}
+
+// OnBelt returns if the dude is carrying the consumable.
+func (d *Dude) OnBelt(eq *Equipment) bool {
+	for _, e := range d.belt {
+		if e == eq {
+			return true
+		}
+	}
+	return false
+}
+
+// BeltFull returns if the dude has no room for more consumables.
+func (d *Dude) BeltFull() bool {
+	return len(d.belt) >= BeltSize
+}
+
+// AddToBelt puts the consumable on the dude's belt, taking it out of the inventory. Returns false if the belt is full.
+func (d *Dude) AddToBelt(eq *Equipment) bool {
+	if d.BeltFull() || d.OnBelt(eq) {
+		return false
+	}
+	d.belt = append(d.belt, eq)
+	for i, e := range d.inventory {
+		if e == eq {
+			d.inventory = append(d.inventory[:i], d.inventory[i+1:]...)
+			break
+		}
+	}
+	d.dirtyEquipment = true
+	return true
+}
+
+// RemoveFromBelt takes the consumable off the dude's belt, returning it if it was there.
+func (d *Dude) RemoveFromBelt(eq *Equipment) *Equipment {
+	for i, e := range d.belt {
+		if e == eq {
+			d.belt = append(d.belt[:i], d.belt[i+1:]...)
+			d.dirtyEquipment = true
+			return eq
+		}
+	}
+	return nil
+}
+
+// BeltString describes the dude's belt and when it gets used, such as "Belt 2/3, below 30% hp\nPotion, Antidote".
+func (d *Dude) BeltString() string {
+	names := []string{"empty"}
+	if len(d.belt) > 0 {
+		names = nil
+		for _, e := range d.belt {
+			names = append(names, e.consumable.String())
+		}
+	}
+	return fmt.Sprintf("Belt %d/%d, below %d%% hp\n%s", len(d.belt), BeltSize, int(math.Round(d.useThreshold*100)), strings.Join(names, ", "))
+}
+
+// UseThreshold returns the hp fraction the dude uses consumables at.
+func (d *Dude) UseThreshold() float64 {
+	return d.useThreshold
+}
+
+// CycleUseThreshold raises the hp fraction the dude uses consumables at, wrapping back around to the lowest.
+func (d *Dude) CycleUseThreshold() {
+	for _, t := range UseThresholds {
+		if t > d.useThreshold+0.01 {
+			d.useThreshold = t
+			return
+		}
+	}
+	d.useThreshold = UseThresholds[0]
+}
+
+// hpFraction returns the dude's current hp as a fraction of their max.
+func (d *Dude) hpFraction() float64 {
+	return float64(d.stats.currentHp) / math.Max(1, float64(d.GetCalculatedStats().totalHp))
+}
+
+// beltItem returns the first consumable of the kind on the dude's belt, or nil if there is none.
+func (d *Dude) beltItem(kind ConsumableKind) *Equipment {
+	for _, e := range d.belt {
+		if e.consumable == kind {
+			return e
+		}
+	}
+	return nil
+}
+
+// UseConsumable uses up the first consumable of the kind on the dude's belt. Returns false if the dude has none.
+func (d *Dude) UseConsumable(kind ConsumableKind) bool {
+	eq := d.beltItem(kind)
+	if eq == nil || d.IsDead() {
+		return false
+	}
+	d.RemoveFromBelt(eq)
+	d.dirtyStats = true
+	d.floatingText(fmt.Sprintf("*%s*", strings.ToLower(eq.DisplayName())), color.NRGBA{200, 150, 255, 200}, 60, 0.5)
+	AddCombatMessage(
+		MessageNeutral,
+		NewCombatEntry(d, CombatActionUse, eq.DisplayName(), 0),
+		fmt.Sprintf("%s used a %s", d.name, eq.DisplayName()),
+	)
+
+	switch kind {
+	case ConsumablePotion:
+		d.Heal(int(math.Ceil(float64(d.GetCalculatedStats().totalHp) * ConsumableHeal)))
+	case ConsumableSmokeBomb:
+		d.enemy = nil
+		if d.room != nil {
+			d.retreatedFrom = d.room
+			d.SetActivity(Retreating)
+		}
+	case ConsumableReturn:
+		d.enemy = nil
+		d.SetActivity(GoingDown)
+	}
+	d.Trigger(EventConsumableUse{dude: d, consumable: eq})
+	return true
+}
+
+// CheckConsumables has a hurt dude reach for their belt: a potion if they have one, otherwise a smoke bomb to escape a fight or a scroll of return when close to death.
+func (d *Dude) CheckConsumables() {
+	if d.IsDead() || len(d.belt) == 0 {
+		return
+	}
+	hp := d.hpFraction()
+	if hp >= d.useThreshold {
+		return
+	}
+	if d.UseConsumable(ConsumablePotion) {
+		return
+	}
+	// There's no escaping a boss.
+	if d.room != nil && d.room.boss != nil {
+		return
+	}
+	if d.enemy != nil && d.activity != Retreating && d.UseConsumable(ConsumableSmokeBomb) {
+		return
+	}
+	if hp < d.useThreshold/2 && d.activity != GoingDown && d.activity != Leaving {
+		d.UseConsumable(ConsumableReturn)
+	}
+}
@@ -0,0 +1,119 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/kettek/ebijam24/internal/render"
+)
+
+// CorpseTax is the fraction of gold lost when recovering gold from a corpse nobody looted.
+const CorpseTax = 0.5
+
+// Corpse marks where a dude fell. It holds onto the dude's loot until another dude passes through and picks it up.
+type Corpse struct {
+	dude   *Dude
+	marker *render.Stack
+	looted bool
+}
+
+// NewCorpse creates a corpse marker at the dude's position.
+func NewCorpse(d *Dude) *Corpse {
+	marker := render.CopyStack(d.shadow)
+	marker.SetOrigin(d.stack.Origin())
+	marker.SetPosition(d.stack.Position())
+	marker.SetRotation(d.stack.Rotation())
+	marker.ColorScale.Reset()
+	marker.ColorScale.Scale(1.5, 0.3, 0.3, 1)
+	return &Corpse{
+		dude:   d,
+		marker: marker,
+	}
+}
+
+// Items returns all the equipment still on the corpse.
+func (c *Corpse) Items() []*Equipment {
+	var items []*Equipment
+	for _, eq := range c.dude.equipped {
+		if eq != nil {
+			items = append(items, eq)
+		}
+	}
+	items = append(items, c.dude.belt...)
+	return append(items, c.dude.inventory...)
+}
+
+// Strip takes all the equipment off the corpse, returning it.
+func (c *Corpse) Strip() []*Equipment {
+	items := c.Items()
+	for t := range c.dude.equipped {
+		delete(c.dude.equipped, t)
+	}
+	c.dude.inventory = make([]*Equipment, 0)
+	c.dude.belt = nil
+	c.dude.dirtyEquipment = true
+	c.dude.dirtyStats = true
+	return items
+}
+
+// Loot moves the corpse's equipment and gold to the looter.
+func (c *Corpse) Loot(looter *Dude) {
+	if c.looted {
+		return
+	}
+	items := c.Strip()
+	for _, eq := range items {
+		looter.AddToInventory(eq)
+	}
+
+	gold := c.dude.gold
+	c.dude.gold = 0
+	if gold > 0 {
+		looter.Trigger(EventGoldGain{dude: looter, amount: gold})
+	}
+
+	c.looted = true
+	c.marker.Transparency = 0.6
+	if len(items) > 0 || gold > 0 {
+		AddMessage(
+			MessageLoot,
+			fmt.Sprintf("%s recovered %d items and %dgp from %s's body", looter.name, len(items), gold, c.dude.name),
+		)
+	}
+}
+
+// Draw draws the corpse marker.
+func (c *Corpse) Draw(o *render.Options) {
+	c.marker.Draw(o)
+}
+
+// AddCorpse leaves a corpse for the dude in the room.
+func (r *Room) AddCorpse(d *Dude) {
+	for _, c := range r.corpses {
+		if c.dude == d {
+			return
+		}
+	}
+	r.corpses = append(r.corpses, NewCorpse(d))
+}
+
+// RemoveCorpse removes the dude's corpse from the room, such as when it is revived.
+func (r *Room) RemoveCorpse(d *Dude) {
+	for i, c := range r.corpses {
+		if c.dude == d {
+			r.corpses = append(r.corpses[:i], r.corpses[i+1:]...)
+			return
+		}
+	}
+}
+
+// LootCorpses lets the dude pick up everything from unlooted corpses in the room.
+func (r *Room) LootCorpses(d *Dude) {
+	if d.IsDead() {
+		return
+	}
+	for _, c := range r.corpses {
+		if c.dude != d {
+			c.Loot(d)
+		}
+	}
+}
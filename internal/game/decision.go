@@ -0,0 +1,326 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+const (
+	WaitTickrate   = 30   // How often waiting dudes in a shrine heal.
+	WaitMaxTicks   = 900  // How long a dude waits before giving up and moving on.
+	WaitShrineHeal = 0.05 // Portion of max HP healed each WaitTickrate in a shrine.
+)
+
+// Decision is what a dude chooses to do at a room boundary.
+type Decision int
+
+const (
+	DecisionContinue  Decision = iota
+	DecisionWaitHeal           // Wait in a healing room until healed.
+	DecisionRetreat            // Back off to the previous room.
+	DecisionWaitParty          // Wait for the rest of the dudes before a boss room.
+	DecisionSkipRoom           // Sneak through an optional room without triggering it.
+)
+
+func (dc Decision) String() string {
+	switch dc {
+	case DecisionContinue:
+		return "continue"
+	case DecisionWaitHeal:
+		return "wait to heal"
+	case DecisionRetreat:
+		return "retreat"
+	case DecisionWaitParty:
+		return "wait for the party"
+	case DecisionSkipRoom:
+		return "skip the room"
+	default:
+		return "unknown"
+	}
+}
+
+// DecisionContext is what a dude knows when making a decision.
+type DecisionContext struct {
+	room       *Room
+	previous   *Room
+	next       *Room
+	center     bool // Whether the dude reached the room's center rather than just entered it.
+	hp         float64
+	morale     float64
+	profession ProfessionKind
+}
+
+// Risk returns how risky the room is for the dude, weighing its danger against the dude's health, morale and profession.
+func (ctx DecisionContext) Risk(r *Room) float64 {
+	if r == nil {
+		return 0
+	}
+	risk := r.Danger() / math.Max(0.1, ctx.hp)
+	risk *= 1.5 - ctx.morale/MoraleMax
+	switch ctx.profession.Base() {
+	case Knight:
+		risk *= 0.75
+	case Cleric, Ranger:
+		risk *= 1.25
+	}
+	return risk
+}
+
+// Behavior decides what a dude does at room boundaries.
+type Behavior interface {
+	Decide(d *Dude, ctx DecisionContext) Decision
+}
+
+// BehaviorKind is a behavior profile that can be assigned to a dude.
+type BehaviorKind int
+
+const (
+	BehaviorBalanced BehaviorKind = iota
+	BehaviorReckless
+	BehaviorCautious
+	BehaviorGreedy
+	// Marker for the end... allows for iteration
+	BehaviorKindEnd
+)
+
+func (b BehaviorKind) String() string {
+	switch b {
+	case BehaviorBalanced:
+		return "Balanced"
+	case BehaviorReckless:
+		return "Reckless"
+	case BehaviorCautious:
+		return "Cautious"
+	case BehaviorGreedy:
+		return "Greedy"
+	default:
+		return "Unknown"
+	}
+}
+
+// Description describes how dudes with the behavior act.
+func (b BehaviorKind) Description() string {
+	switch b {
+	case BehaviorBalanced:
+		return "heals up at shrines and backs off when nearly dead"
+	case BehaviorReckless:
+		return "never stops, never backs down"
+	case BehaviorCautious:
+		return "heals fully, waits for the party and avoids danger"
+	case BehaviorGreedy:
+		return "never passes up a room with loot in it"
+	default:
+		return ""
+	}
+}
+
+// Behavior returns the behavior for the kind.
+func (b BehaviorKind) Behavior() Behavior {
+	switch b {
+	case BehaviorReckless:
+		return ProfileBehavior{}
+	case BehaviorCautious:
+		return ProfileBehavior{healTo: 1, retreatBelow: 0.35, skipRisk: 3, waitParty: true}
+	case BehaviorGreedy:
+		return ProfileBehavior{healTo: 0.5, retreatBelow: 0.2, skipRisk: 4, greedy: true}
+	default:
+		return ProfileBehavior{healTo: 0.75, retreatBelow: 0.2, skipRisk: 8}
+	}
+}
+
+// ProfileBehavior is a behavior tuned by thresholds. Zero values turn the matching decision off.
+type ProfileBehavior struct {
+	healTo       float64 // HP fraction to wait for in a healing room.
+	retreatBelow float64 // HP fraction below which to retreat from a dangerous room.
+	skipRisk     float64 // Risk at or above which optional rooms are skipped.
+	waitParty    bool    // Whether to wait for the rest of the dudes before a boss room.
+	greedy       bool    // Whether rooms with loot are never skipped.
+}
+
+// Decide picks what to do at a room boundary.
+func (p ProfileBehavior) Decide(d *Dude, ctx DecisionContext) Decision {
+	r := ctx.room
+	if ctx.center {
+		if r.kind == HealingShrine && ctx.hp < p.healTo {
+			return DecisionWaitHeal
+		}
+		if p.waitParty && ctx.next != nil && ctx.next.kind == Boss && !ctx.next.killedBoss && d.PartyBehind(r) {
+			return DecisionWaitParty
+		}
+		return DecisionContinue
+	}
+
+	if r.Danger() == 0 {
+		return DecisionContinue
+	}
+	if ctx.hp < p.retreatBelow && ctx.previous != nil && ctx.previous.kind != Stairs && ctx.previous.Danger() < r.Danger() && d.retreatedFrom != r {
+		return DecisionRetreat
+	}
+	if p.skipRisk > 0 && r.Skippable() && ctx.Risk(r) >= p.skipRisk {
+		if p.greedy && r.kind.Equipment() != nil {
+			return DecisionContinue
+		}
+		return DecisionSkipRoom
+	}
+	return DecisionContinue
+}
+
+// Danger returns how dangerous the room is to walk through, 0 being harmless.
+func (r *Room) Danger() float64 {
+	switch r.kind {
+	case Combat:
+		return float64(r.size)
+	case Trap:
+		return float64(r.size) * 0.75
+	case Curse:
+		return 1
+	case Boss:
+		if r.killedBoss {
+			return 0
+		}
+		return 5
+	default:
+		return 0
+	}
+}
+
+// Skippable returns if dudes may sneak through the room without triggering it.
+func (r *Room) Skippable() bool {
+	return !r.required && r.kind != Boss && r.kind != Stairs
+}
+
+// PreviousRoom returns the room before this one, or nil if it is the first.
+func (r *Room) PreviousRoom() *Room {
+	if r.story == nil || r.index <= 0 {
+		return nil
+	}
+	return r.story.rooms[r.index-1]
+}
+
+// NextRoom returns the room after this one, or nil if it is the last.
+func (r *Room) NextRoom() *Room {
+	if r.story == nil || r.index+int(r.size) >= len(r.story.rooms) {
+		return nil
+	}
+	return r.story.rooms[r.index+int(r.size)]
+}
+
+// Behavior returns the dude's behavior profile.
+func (d *Dude) Behavior() BehaviorKind {
+	return d.behavior
+}
+
+// SetBehavior assigns the dude's behavior profile.
+func (d *Dude) SetBehavior(b BehaviorKind) {
+	d.behavior = b
+	d.dirtyStats = true
+}
+
+// CycleBehavior switches the dude to the next behavior profile.
+func (d *Dude) CycleBehavior() {
+	d.SetBehavior((d.behavior + 1) % BehaviorKindEnd)
+}
+
+// ResetDecisions forgets the rooms the dude skipped or retreated from.
+func (d *Dude) ResetDecisions() {
+	d.decision = DecisionContinue
+	d.skipping = nil
+	d.retreatedFrom = nil
+}
+
+// DecisionContext returns what the dude knows about the room it entered or reached the center of.
+func (d *Dude) DecisionContext(r *Room, center bool) DecisionContext {
+	stats := d.GetCalculatedStats()
+	return DecisionContext{
+		room:       r,
+		previous:   r.PreviousRoom(),
+		next:       r.NextRoom(),
+		center:     center,
+		hp:         float64(d.stats.currentHp) / math.Max(1, float64(stats.totalHp)),
+		morale:     d.morale,
+		profession: d.profession,
+	}
+}
+
+// Decide runs the dude's behavior at a room boundary and acts on it. Returns true if the room should be ignored.
+func (d *Dude) Decide(r *Room, center bool) bool {
+	if r == nil || d.IsDead() || (d.activity != Moving && d.activity != Centering) {
+		return false
+	}
+	decision := d.behavior.Behavior().Decide(d, d.DecisionContext(r, center))
+	switch decision {
+	case DecisionWaitHeal, DecisionWaitParty:
+		d.SetActivity(Waiting)
+		d.decision = decision
+		d.floatingText("*wait*", color.NRGBA{200, 255, 200, 200}, 60, 0.5)
+	case DecisionRetreat:
+		d.enemy = nil
+		d.retreatedFrom = r
+		d.SetActivity(Retreating)
+		d.decision = decision
+		d.floatingText("*retreat*", color.NRGBA{200, 200, 255, 200}, 60, 0.5)
+		kind := r.kind
+		AddMessage(
+			MessageBad,
+			fmt.Sprintf("%s is too hurt to enter the %s and fell back", d.name, kind.String()),
+		)
+		return true
+	case DecisionSkipRoom:
+		d.skipping = r
+		d.floatingText("*sneak*", color.NRGBA{200, 200, 200, 200}, 60, 0.5)
+		kind := r.kind
+		AddMessage(
+			MessageNeutral,
+			fmt.Sprintf("%s snuck past the %s", d.name, kind.String()),
+		)
+		return true
+	}
+	return false
+}
+
+// Ignores returns if the dude is paying no mind to the room's effects.
+func (d *Dude) Ignores(r *Room) bool {
+	return d.activity == Leaving || d.activity == Retreating || d.activity == GoingDown || (r != nil && d.skipping == r)
+}
+
+// Wait has the dude wait in place, healing now and then if in a shrine. Returns true once the dude is done waiting.
+func (d *Dude) Wait() bool {
+	d.timer++
+	if d.timer%WaitTickrate == 0 && d.room != nil && d.room.kind == HealingShrine {
+		d.Heal(int(math.Ceil(float64(d.GetCalculatedStats().totalHp) * WaitShrineHeal)))
+	}
+	if d.timer >= WaitMaxTicks {
+		return true
+	}
+	switch d.decision {
+	case DecisionWaitParty:
+		return !d.PartyBehind(d.room)
+	default:
+		healTo := 0.5
+		if p, ok := d.behavior.Behavior().(ProfileBehavior); ok {
+			healTo = math.Max(healTo, p.healTo)
+		}
+		stats := d.GetCalculatedStats()
+		return float64(d.stats.currentHp) >= float64(stats.totalHp)*healTo
+	}
+}
+
+// PartyBehind returns if any other dude of the same party still in the tower has yet to catch up to the given room.
+func (d *Dude) PartyBehind(r *Room) bool {
+	if r == nil || d.story == nil || d.story.tower == nil {
+		return false
+	}
+	for _, other := range d.story.tower.dudes {
+		if other == d || other.party != d.party || other.IsDead() || other.story == nil || other.activity == Leaving || other.activity == GoingDown {
+			continue
+		}
+		if other.story.level < r.story.level {
+			return true
+		}
+		if other.story == r.story && (other.room == nil || other.room.index < r.index) {
+			return true
+		}
+	}
+	return false
+}
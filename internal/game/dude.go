@@ -6,6 +6,7 @@ import (
 	"math"
 	"math/rand"
 	"sort"
+	"strings"
 
 	"github.com/kettek/ebijam24/assets"
 	"github.com/kettek/ebijam24/internal/render"
@@ -26,7 +27,8 @@ const (
 	EnterPortal
 	Ded
 	BornAgain
-	Waiting // maybe some random shufflin about
+	Waiting    // Waiting on something the dude decided to wait for.
+	Retreating // Backing off to the previous room.
 	FightBoss
 )
 
@@ -37,17 +39,40 @@ type Dude struct {
 	gold         int
 	profession   ProfessionKind
 	stats        Stats
-	equipped     map[EquipmentType]*Equipment
+	equipped     map[EquipmentSlot]*Equipment
 	inventory    []*Equipment
-	story        *Story // current story da dude be in
-	room         *Room  // current room the dude is in
+	belt         []*Equipment // consumables carried into the tower
+	useThreshold float64      // hp fraction consumables get used at
+	story        *Story       // current story da dude be in
+	room         *Room        // current room the dude is in
 	stack        *render.Stack
 	shadow       *render.Stack
 	timer        int
 	activity     DudeActivity
 	activityDone bool
 	variation    float64
-	enemy        *Enemy  // currently fighting enemy
+	enemy        *Enemy // currently fighting enemy
+	morale       float64
+	steering     Steering
+	taxMood      int // confidence gained or lost from the tax rate
+	runGold      int // gold earned this run, the only gold that gets taxed
+	party        int // index of the party the dude sets out with
+	// Decision making
+	behavior      BehaviorKind
+	decision      Decision
+	skipping      *Room // room being snuck through
+	retreatedFrom *Room // last room the dude backed off from
+	// Max hp lost from being revived, restored on level up
+	revivalPenalty int
+	traits         []TraitKind
+	skills         map[SkillKind]int
+	skillPoints    int
+	// Counters used for promotion requirements
+	kills        int
+	bossKills    int
+	crits        int
+	healingDone  int
+	itemsUsed    int
 	trueRotation float64 // This is the absolute rotation of the dude, ignoring facing.
 	// for updating dude infos
 	dirtyEquipment bool
@@ -90,15 +115,20 @@ func NewDude(pk ProfessionKind, level int) *Dude {
 	for i := 0; i < level-1; i++ {
 		dude.stats.LevelUp(false)
 	}
+	dude.traits = RollTraits()
+	dude.skills = make(map[SkillKind]int)
+	dude.skillPoints = level - 1
 
 	dude.inventory = make([]*Equipment, 0)
+	dude.useThreshold = DefaultUseThreshold
 
-	dude.equipped = make(map[EquipmentType]*Equipment)
+	dude.equipped = make(map[EquipmentSlot]*Equipment)
 	for _, eq := range profession.StartingEquipment() {
 		dude.Equip(eq)
 	}
 
 	dude.variation = -6 + rand.Float64()*12
+	dude.ResetMorale()
 
 	dude.stack = stack
 	dude.stack.VgroupOffset = 1
@@ -109,6 +139,7 @@ func NewDude(pk ProfessionKind, level int) *Dude {
 func (d *Dude) SetActivity(a DudeActivity) {
 	d.activity = a
 	d.timer = 0
+	d.steering.Clear()
 	if a == Ded {
 		d.stack.SetAnimation("ded")
 	} else if a == BornAgain {
@@ -117,7 +148,6 @@ func (d *Dude) SetActivity(a DudeActivity) {
 }
 
 func (d *Dude) Update(story *Story, req *ActivityRequests) {
-	// NOTE: We should replace Centering/Moving direct position/rotation setting with a "pathing node" that the dude seeks to follow. This would allow more smoothly doing turns and such, as we could have a turn limit the dude would follow automatically...
 	switch d.activity {
 	case Idle:
 		// Do nothing.
@@ -126,44 +156,29 @@ func (d *Dude) Update(story *Story, req *ActivityRequests) {
 	case BornAgain:
 		d.SetActivity(Moving) // Is this safe to just set moving?
 	case FirstEntering:
-		cx, cy := d.Position()
-		distance := story.DistanceFromCenter(cx, cy)
-		if distance < 50+d.variation {
+		if d.steering.Done() {
+			// Walk straight in from the outside.
+			d.steering.Queue(Waypoint{angle: d.Angle(story), distance: 50 + d.variation})
+		}
+		d.stack.HeightOffset -= 0.15
+		if d.stack.HeightOffset <= 0 {
+			d.stack.HeightOffset = 0
+		}
+		if !d.Steer(story, d.Speed(), req) {
 			d.SetActivity(Centering)
 			d.stack.HeightOffset = 0
-		} else {
-			r := story.AngleFromCenter(cx, cy) + d.variation/5000
-			nx, ny := story.PositionFromCenter(r, distance-d.Speed()*100)
-
-			face := math.Atan2(ny-cy, nx-cx)
-			d.trueRotation = face
-
-			req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
-				d.stack.HeightOffset -= 0.15
-				if d.stack.HeightOffset <= 0 {
-					d.stack.HeightOffset = 0
-				}
-				d.SyncEquipment()
-			}})
 		}
 	case StairsToUp:
 		d.timer++
 
 		if d.timer < 40 {
-			cx, cy := d.Position()
-			r := story.AngleFromCenter(cx, cy) + d.variation/5000
-			nx, ny := story.PositionFromCenter(r-0.005, RoomPath+d.variation)
-
-			d.stack.VgroupOffset = d.timer / 2
-
-			face := math.Atan2(ny-cy, nx-cx)
-			d.trueRotation = face
-
-			req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
-				if success {
-					d.SyncEquipment()
+			if d.steering.Done() {
+				if stairs := story.Paths().Stairs(); stairs != nil {
+					d.steering.Queue(stairs.Waypoint(d.variation))
 				}
-			}})
+			}
+			d.stack.VgroupOffset = d.timer / 2
+			d.Steer(story, StairsClimbSpeed, req)
 		} else {
 			req.Add(StoryEnterNextActivity{dude: d, cb: func(success bool) {
 				if success {
@@ -179,16 +194,7 @@ func (d *Dude) Update(story *Story, req *ActivityRequests) {
 			d.stack.SliceOffset = d.stack.SliceCount()
 			d.stack.MaxSliceIndex = 1
 		}
-		cx, cy := d.Position()
-		r := story.AngleFromCenter(cx, cy) + d.variation/5000
-		nx, ny := story.PositionFromCenter(r-0.01, RoomPath+d.variation)
-
-		face := math.Atan2(ny-cy, nx-cx)
-		d.trueRotation = face
-
-		req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
-			d.SyncEquipment()
-		}})
+		d.FollowPath(story, StairsClimbSpeed*2, req)
 		if d.timer >= 2 {
 			d.stack.SliceOffset--
 			d.stack.MaxSliceIndex++
@@ -205,17 +211,9 @@ func (d *Dude) Update(story *Story, req *ActivityRequests) {
 			d.stack.SliceOffset = d.stack.SliceCount()
 			d.stack.MaxSliceIndex = 1
 			cx, cy := d.Position()
-			distance := story.DistanceFromCenter(cx, cy)
-			r := story.AngleFromCenter(cx, cy)
-			nx, ny := story.PositionFromCenter(r, distance+d.Speed()*100)
-
-			face := math.Atan2(ny-cy, nx-cx)
-			d.trueRotation = face
-
-			req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
-				d.SyncEquipment()
-			}})
+			d.steering.Queue(Waypoint{angle: d.Angle(story), distance: story.DistanceFromCenter(cx, cy) + d.Speed()*SteeringRadialScale})
 		}
+		d.Steer(story, d.Speed(), req)
 		if d.timer >= 15 {
 			d.stack.SliceOffset--
 			d.stack.MaxSliceIndex++
@@ -228,72 +226,75 @@ func (d *Dude) Update(story *Story, req *ActivityRequests) {
 		}
 	case Centering:
 		cx, cy := d.Position()
-		distance := story.DistanceFromCenter(cx, cy)
-		if distance >= RoomPath+d.variation {
+		if story.DistanceFromCenter(cx, cy) >= RoomPath+d.variation {
 			d.SetActivity(Moving)
-		} else {
-			r := story.AngleFromCenter(cx, cy) + d.variation/5000
-			nx, ny := story.PositionFromCenter(r, distance+d.Speed()*100)
-
-			face := math.Atan2(ny-cy, nx-cx)
-			d.trueRotation = face
-
-			req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
-				if success {
-					d.SyncEquipment()
-				}
-			}})
+			break
 		}
-	case Moving:
-		cx, cy := d.Position()
-		r := story.AngleFromCenter(cx, cy) + d.variation/5000
-		nx, ny := story.PositionFromCenter(r-d.Speed(), RoomPath+d.variation)
-
-		face := math.Atan2(ny-cy, nx-cx)
-		d.trueRotation = face
-
-		// Face inwards if we have an enemy!
-		if d.enemy != nil {
-			fx, fy := story.PositionFromCenter(r-d.Speed(), d.variation)
-			face = math.Atan2(fy-cy, fx-cx)
+		if d.steering.Done() {
+			// Step out onto the path.
+			d.steering.Queue(Waypoint{angle: d.Angle(story), distance: RoomPath + d.variation})
 		}
-
-		req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
-			if success {
-				d.SyncEquipment()
-			}
-		}})
+		if !d.Steer(story, d.Speed(), req) {
+			d.SetActivity(Moving)
+		}
+	case Moving:
+		d.FollowPath(story, d.Speed(), req)
 	case Leaving:
-		// TODO
+		d.Retrace(story, d.Speed(), req)
+	case Retreating:
+		if d.steering.Done() && d.retreatedFrom != nil {
+			if prev := story.Paths().RoomCenter(d.retreatedFrom.PreviousRoom()); prev != nil {
+				d.steering.Queue(prev.Waypoint(d.variation))
+			}
+		}
+		if !d.Steer(story, d.Speed(), req) {
+			d.SetActivity(Waiting)
+		}
+	case Waiting:
+		if d.Wait() {
+			d.SetActivity(Moving)
+		}
 	case GoingDown:
-		// TODO
+		d.timer++
+		if d.stack.MaxSliceIndex == 0 {
+			d.stack.MaxSliceIndex = d.stack.SliceCount()
+		}
+		if d.timer >= 2 {
+			d.stack.SliceOffset++
+			d.stack.MaxSliceIndex--
+			d.timer = 0
+		}
+		if d.stack.MaxSliceIndex <= 1 {
+			d.stack.SliceOffset = 0
+			d.stack.MaxSliceIndex = 0
+			d.stack.Transparency = 1
+			d.shadow.Transparency = 1
+			d.SetActivity(Idle)
+			req.Add(TowerFleeActivity{dude: d})
+		}
 	case EnterPortal:
 		d.timer++
 		// Wait a little bit before entering!
 		if d.timer >= 30 {
-			cx, cy := d.Position()
-			distance := story.DistanceFromCenter(cx, cy)
-
-			d.stack.Transparency = float32(d.timer-30) / 20
-			d.shadow.Transparency = float32(d.timer-30) / 20
-
-			if distance < PortalDistance-4+d.variation {
+			// Fade out on the way, but don't vanish until we're in.
+			d.stack.Transparency = float32(math.Min(float64(d.timer-30)/30, 0.9))
+			d.shadow.Transparency = d.stack.Transparency
+
+			if d.steering.Done() {
+				portal := story.Paths().Portal()
+				if portal == nil {
+					// No portal to walk to, just head inwards.
+					portal = &PathNode{kind: PathNodePortal, angle: d.Angle(story), distance: PortalDistance}
+				}
+				d.steering.Queue(portal.Waypoint(d.variation - 4))
+			}
+			if !d.Steer(story, PortalWalkSpeed, req) {
 				d.stack.Transparency = 1
 				d.shadow.Transparency = 1
 				d.SetActivity(Idle)
 				if !d.IsDead() {
 					req.Add(TowerLeaveActivity{dude: d})
 				}
-			} else {
-				r := story.AngleFromCenter(cx, cy)
-				nx, ny := story.PositionFromCenter(r, distance-0.005*100)
-
-				face := math.Atan2(ny-cy, nx-cx)
-				d.trueRotation = face
-
-				req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
-					d.SyncEquipment()
-				}})
 			}
 		}
 
@@ -365,18 +366,22 @@ func (d *Dude) DrawProfile(o *render.Options) {
 	stack.SetRotation(-math.Pi / 2)
 	stack.Draw(o)
 
-	// Draw armor (like helmet or soemthing) ?
-	armor := d.equipped[EquipmentTypeArmor]
-	if armor != nil && armor.stack != nil {
-		stack = render.CopyStack(armor.stack)
-		stack.SetPosition(0, 0)
-		stack.SetOrigin(0, 0)
-		stack.SetRotation(-math.Pi / 2)
-		stack.Draw(o)
+	// Draw armor and helmet
+	for _, slot := range []EquipmentSlot{EquipmentSlotBody, EquipmentSlotHead} {
+		armor := d.equipped[slot]
+		if armor != nil && armor.stack != nil {
+			stack = render.CopyStack(armor.stack)
+			stack.SetPosition(0, 0)
+			stack.SetOrigin(0, 0)
+			stack.SetRotation(-math.Pi / 2)
+			stack.Draw(o)
+		}
 	}
 }
 
 func (d *Dude) Trigger(e Event) Activity {
+	wasAlive := !d.IsDead()
+
 	// Trigger equipped equipment
 	// It may modify event amounts
 	for _, eq := range d.equipped {
@@ -384,66 +389,94 @@ func (d *Dude) Trigger(e Event) Activity {
 			eq.Activate(e)
 		}
 	}
+	d.TriggerSetPerks(e)
+
+	// Trigger traits
+	for _, t := range d.traits {
+		t.Check(d, e)
+	}
+
+	// Trigger learned skills
+	for sk, rank := range d.skills {
+		combatLog.source = sk.String()
+		sk.Check(d, rank, e)
+		combatLog.source = ""
+	}
+
+	d.UpdateMorale(e)
 
 	switch e := e.(type) {
 	case EventDudeHit:
 		if d.IsDead() {
 			return DudeDeadActivity{dude: d}
 		}
+		d.CheckConsumables()
+	case EventConsumableUse:
+		d.itemsUsed++
 	case EventCombatRoom:
-		// Can't fight if u ded
+		// Can't fight if u ded, though a skill's kill may have just blown the dude up.
 		if d.IsDead() {
+			if wasAlive {
+				return DudeDeadActivity{dude: d}
+			}
 			return nil
 		}
 		// Attack enemy if there is one
 		if d.enemy != nil {
 			damage, isCrit := d.GetDamage()
-			if damage == 0 {
+			enemyName := d.enemy.Name()
+			evaded := damage > 0 && d.enemy.Evades(d)
+			// Attacks land for the dude's strength, lifted by morale.
+			hit := int(float64(d.stats.strength) * d.MoraleDamageMultiplier())
+			if evaded {
+				damage = 0
+			} else if damage == 0 {
 				d.Trigger(EventDudeMiss{dude: d, enemy: d.enemy})
-				AddMessage(
+				AddCombatMessage(
 					MessageNeutral,
-					fmt.Sprintf("%s missed their attack against %s!", d.name, d.enemy.name),
+					NewCombatEntry(d, CombatActionMiss, enemyName, 0),
+					fmt.Sprintf("%s missed their attack against %s!", d.name, enemyName),
 				)
 			} else if isCrit {
-				AddMessage(
+				d.crits++
+				AddCombatMessage(
 					MessageGood,
-					fmt.Sprintf("%s crit %s for %d damage!", d.name, d.enemy.name, damage),
+					NewCombatEntry(d, CombatActionCrit, enemyName, damage),
+					fmt.Sprintf("%s crit %s for %d damage!", d.name, enemyName, damage),
 				)
 				d.Trigger(EventDudeCrit{dude: d, enemy: d.enemy, amount: damage})
-			}
-			enemyKilled := d.enemy.Damage(d.stats.strength)
-
-			if enemyKilled {
-				xp := d.enemy.XP()
-				gold := d.enemy.Gold()
-				d.Trigger(EventGoldGain{dude: d, amount: gold})
-				d.AddXP(xp)
-				AddMessage(
-					MessageGood,
-					fmt.Sprintf("%s defeated %s and gained %d xp and %d gp", d.name, d.enemy.name, xp, gold),
-				)
-				if d.room != nil {
-					loot := d.room.RollLoot(d.GetCalculatedStats().luck)
-					if loot != nil {
-						d.AddToInventory(loot)
+				// Dirty fighting may have finished the enemy off already.
+				if d.enemy == nil {
+					if d.IsDead() {
+						return DudeDeadActivity{dude: d}
 					}
+					return nil
+				}
+			} else {
+				AddCombatEntry(NewCombatEntry(d, CombatActionHit, enemyName, hit))
+			}
+			if !evaded && d.DamageEnemy(hit) {
+				if d.IsDead() {
+					return DudeDeadActivity{dude: d}
 				}
-				d.enemy = nil
 			} else {
 				takenDamage, isDodge := d.ApplyDamage(d.enemy.Hit())
 				if !isDodge {
-					if act := d.Trigger(EventDudeHit{dude: d, amount: takenDamage}); act != nil {
+					d.enemy.OnHit(takenDamage)
+					if act := d.Trigger(EventDudeHit{dude: d, enemy: d.enemy, room: d.room, amount: takenDamage}); act != nil {
 						return act
 					}
-					AddMessage(
+					AddCombatMessage(
 						MessageBad,
-						fmt.Sprintf("%s took %d damage from %s", d.name, takenDamage, d.enemy.name),
+						NewCombatEntryAgainst(d, CombatActionHit, enemyName, takenDamage),
+						fmt.Sprintf("%s took %d damage from %s", d.name, takenDamage, enemyName),
 					)
 				} else {
 					d.Trigger(EventDudeDodge{dude: d, enemy: d.enemy})
-					AddMessage(
+					AddCombatMessage(
 						MessageNeutral,
-						fmt.Sprintf("%s dodged an attack from %s", d.name, d.enemy.name),
+						NewCombatEntryAgainst(d, CombatActionDodge, enemyName, 0),
+						fmt.Sprintf("%s dodged an attack from %s", d.name, enemyName),
 					)
 				}
 				if d.IsDead() {
@@ -452,22 +485,39 @@ func (d *Dude) Trigger(e Event) Activity {
 				}
 			}
 		}
-		// Else it may be a trap room
-		if act := d.room.GetRoomEffect(e); act != nil {
-			return act
+		// Else it may be a trap room, unless we're sneaking through it
+		if d.Ignores(d.room) {
+			return nil
 		}
-	case EventEnterRoom:
 		if act := d.room.GetRoomEffect(e); act != nil {
 			return act
 		}
-	case EventCenterRoom:
-		if act := d.room.GetRoomEffect(e); act != nil {
-			return act
+	case EventEnterRoom, EventCenterRoom, EventLeaveRoom:
+		switch e := e.(type) {
+		case EventEnterRoom:
+			d.skipping = nil
+			// Hurt dudes with gold in their pockets patch themselves up.
+			if d.activity != Leaving && d.activity != Retreating {
+				d.SpendOnHealing()
+			}
+			if d.Decide(e.room, false) {
+				return nil
+			}
+		}
+		// Even fleeing and sneaking dudes grab what the fallen left behind on their way through.
+		if e, ok := e.(EventLeaveRoom); ok && e.room != nil {
+			e.room.LootCorpses(d)
+		}
+		// Fleeing and sneaking dudes don't stop for anything.
+		if d.Ignores(d.room) {
+			return nil
 		}
-	case EventLeaveRoom:
 		if act := d.room.GetRoomEffect(e); act != nil {
 			return act
 		}
+		if _, ok := e.(EventCenterRoom); ok {
+			d.Decide(d.room, true)
+		}
 	case EventEquip:
 		//fmt.Println(d.name, "equipped", e.equipment.Name())
 		if d.stack != nil {
@@ -486,7 +536,7 @@ func (d *Dude) Trigger(e Event) Activity {
 		//fmt.Println(d.name, "gained", e.amount, "gold")
 		d.floatingText(fmt.Sprintf("+%dgp", e.amount), color.NRGBA{255, 255, 0, 255}, 40, 0.6)
 	case EventGoldLoss:
-		d.UpdateGold(e.amount)
+		d.UpdateGold(-e.amount)
 		//fmt.Println(d.name, "lost", e.amount, "gold")
 		d.floatingText(fmt.Sprintf("-%dgp", e.amount), color.NRGBA{255, 255, 0, 255}, 40, 0.4)
 	}
@@ -547,6 +597,62 @@ func (d *Dude) Speed() float64 {
 	return baseSpeed * (1 + float64(stats.agility/10)*speedScale)
 }
 
+// DamageEnemy deals damage to the dude's enemy, rewarding the dude if it dies. Returns true if the enemy was killed.
+func (d *Dude) DamageEnemy(amount int) bool {
+	enemy := d.enemy
+	if enemy == nil {
+		return false
+	}
+	enemyName := enemy.Name()
+	killed := enemy.Damage(amount)
+	if amount > 0 {
+		d.Trigger(EventEnemyHit{dude: d, enemy: enemy, amount: amount})
+	}
+	if !killed {
+		return false
+	}
+	d.kills++
+	d.AdjustMorale(5)
+	if enemy.rank != EnemyRankNormal {
+		AddMessage(
+			MessageGood,
+			fmt.Sprintf("%s slew the %s %s!", d.name, strings.ToLower(enemy.rank.String()), enemyName),
+		)
+	}
+	xp := enemy.XP()
+	gold := enemy.Gold()
+	d.Trigger(EventGoldGain{dude: d, amount: gold})
+	d.AddXP(xp)
+	AddCombatMessage(
+		MessageGood,
+		NewCombatEntry(d, CombatActionDeath, enemyName, 0),
+		fmt.Sprintf("%s defeated %s and gained %d xp and %d gp", d.name, enemyName, xp, gold),
+	)
+	if d.story != nil {
+		for _, loot := range enemy.RollLoot(d.GetCalculatedStats().luck, d.story.level) {
+			d.AddToInventory(loot)
+			AddCombatMessage(
+				MessageLoot,
+				NewCombatEntry(d, CombatActionLoot, loot.Name(), 0),
+				fmt.Sprintf("%s looted %s from %s", d.name, loot.Name(), enemyName),
+			)
+		}
+	}
+	// Explosive enemies take their killer with them, or try to.
+	if damage := enemy.DeathDamage(); damage > 0 {
+		takenDamage, isDodge := d.ApplyDamage(damage)
+		if !isDodge {
+			AddCombatMessage(
+				MessageBad,
+				NewCombatEntryAgainst(d, CombatActionHit, enemyName, takenDamage),
+				fmt.Sprintf("%s exploded, dealing %d damage to %s", enemyName, takenDamage, d.name),
+			)
+		}
+	}
+	d.enemy = nil
+	return true
+}
+
 // TODO: Refine this
 func (d *Dude) GetDamage() (int, bool) {
 	wasCrit := false
@@ -580,7 +686,7 @@ func (d *Dude) GetDamage() (int, bool) {
 		multiplier = 0.0
 	}
 
-	amount := int(float64(stats.strength) * multiplier)
+	amount := int(float64(stats.strength) * multiplier * d.MoraleDamageMultiplier())
 	return amount, wasCrit
 }
 
@@ -596,6 +702,9 @@ func (d *Dude) ApplyDamage(amount int) (int, bool) {
 	agilityContribution := float64(stats.agility) * 0.01 // 1% per agility point
 
 	dodgeChance := baseChance + luckContribution + agilityContribution
+	for _, t := range d.traits {
+		dodgeChance += t.DodgeBonus()
+	}
 
 	// Cap the maximum dodge chance at 50%
 	chance := math.Min(dodgeChance, 0.5)
@@ -625,8 +734,13 @@ func (d *Dude) ApplyDamage(amount int) (int, bool) {
 		d.SetActivity(Ded)
 
 		d.floatingText("RIP", color.NRGBA{64, 64, 64, 255}, 80, 1)
-		AddMessage(
+		source := ""
+		if d.enemy != nil {
+			source = d.enemy.Name()
+		}
+		AddCombatMessage(
 			MessageBad,
+			NewCombatEntryAgainst(d, CombatActionDeath, source, amount),
 			fmt.Sprintf("%s took %d damage and was defeated", d.name, amount),
 		)
 	} else {
@@ -650,18 +764,38 @@ func (d *Dude) Gold() int {
 
 func (d *Dude) UpdateGold(amount int) {
 	d.gold += amount
+	if amount > 0 {
+		d.runGold += amount
+	}
 	if d.gold < 0 {
 		d.gold = 0
 	}
 }
 
-// Equips item to dude
-func (d *Dude) Equip(eq *Equipment) *Equipment {
-	e := d.Unequip(eq.Type())
-	if e != nil {
-		d.Trigger(EventUnequip{dude: d, equipment: e}) // Event isolated to dude?
+// RefundGold gives the dude gold back without counting it as earned, so it isn't taxed.
+func (d *Dude) RefundGold(amount int) {
+	d.gold += amount
+}
+
+// Equips item to dude in the best slot for it, returning what was taken off to make room. Consumables go on the belt, and come straight back if it's full.
+func (d *Dude) Equip(eq *Equipment) []*Equipment {
+	if eq.IsConsumable() {
+		if !d.AddToBelt(eq) {
+			return []*Equipment{eq}
+		}
+		return nil
+	}
+	return d.EquipInSlot(eq, d.SlotFor(eq))
+}
+
+// Equips item to dude in the given slot, returning what was taken off to make room
+func (d *Dude) EquipInSlot(eq *Equipment, slot EquipmentSlot) []*Equipment {
+	displaced := d.Displaced(eq, slot)
+	for _, e := range displaced {
+		d.Unequip(e)
 	}
-	d.equipped[eq.Type()] = eq
+	d.Unequip(eq) // In case it's moving between slots
+	d.equipped[slot] = eq
 	d.Trigger(EventEquip{dude: d, equipment: eq}) // Event isolated to dude?
 
 	// If equipment is in inventory, remove it
@@ -672,30 +806,23 @@ func (d *Dude) Equip(eq *Equipment) *Equipment {
 		}
 	}
 	d.dirtyEquipment = true
-	return e
+	return displaced
 }
 
-func (d *Dude) Unequip(t EquipmentType) *Equipment {
-	if e, ok := d.equipped[t]; ok {
-		d.Trigger(EventUnequip{dude: d, equipment: e}) // Event isolated to dude?
+// Unequips the item from whatever slot it's in, returning it if it was equipped
+func (d *Dude) Unequip(eq *Equipment) *Equipment {
+	if slot, ok := d.SlotOf(eq); ok {
+		d.Trigger(EventUnequip{dude: d, equipment: eq}) // Event isolated to dude?
 		// Delete the equipment from the equipped map
-		delete(d.equipped, t)
+		delete(d.equipped, slot)
 		d.dirtyEquipment = true
-		return e // Return the unequipped item
+		return eq // Return the unequipped item
 	}
 	return nil
 }
 
 func (d *Dude) CanEquip(eq *Equipment) bool {
-	equippable := len(eq.professions) == 0
-	if !equippable {
-		for _, p := range eq.professions {
-			if p == d.profession {
-				return true
-			}
-		}
-	}
-	return false
+	return eq.CanEquip(d.profession)
 }
 
 // If the provided equipment should replace currently equipped item:
@@ -703,54 +830,54 @@ func (d *Dude) CanEquip(eq *Equipment) bool {
 //   - new item is better
 //   - new item belongs to profession
 func (d *Dude) ShouldEquip(eq *Equipment) bool {
-	equippable := len(eq.professions) == 0
-	if !equippable {
-		for _, p := range eq.professions {
-			if p == d.profession {
-				equippable = true
-				break
-			}
+	if eq.IsConsumable() {
+		return !d.OnBelt(eq) && !d.BeltFull()
+	}
+	equippable := eq.CanEquip(d.profession)
+
+	if _, worn := d.SlotOf(eq); worn {
+		return false
+	}
+	displaced := d.Displaced(eq, d.SlotFor(eq))
+	if len(displaced) == 0 {
+		return equippable
+	}
+	// Weigh against the best of what would come off
+	equipped := displaced[0]
+	for _, e := range displaced[1:] {
+		if e.LevelWithQuality() > equipped.LevelWithQuality() {
+			equipped = e
 		}
 	}
 
-	equipped := d.equipped[eq.Type()]
-	if equipped == nil {
+	// Completing a set bonus is worth more than a better item, and breaking one is worth less.
+	setBonuses := len(d.SetBonuses())
+	newSetBonuses := len(d.SetBonusesWith(eq))
+	if newSetBonuses > setBonuses {
 		return equippable
+	} else if newSetBonuses < setBonuses {
+		return false
 	}
 
 	newItemBetter := eq.LevelWithQuality() > equipped.LevelWithQuality()
 
-	ep := equipped.perk
-	np := eq.perk
-	if ep == nil {
-		return equippable && newItemBetter
-	} else if np == nil {
-		return false
+	// Every perk coming off has to be matched by one at least as good.
+	for _, p := range equipped.Perks() {
+		if !eq.HasPerkLike(p) {
+			return false
+		}
 	}
-
-	newItemPerkBetter := np.Name() == ep.Name() && np.Quality() >= ep.Quality()
-	return equippable && newItemPerkBetter && newItemBetter
+	return equippable && newItemBetter
 }
 
 func (d *Dude) AddToInventory(eq *Equipment) {
 	d.inventory = append(d.inventory, eq)
 	shouldEquip := d.ShouldEquip(eq)
 
-	professions := eq.professions
-	hasProfession := false
-	if len(professions) == 0 {
-		hasProfession = true
-	} else {
-		for _, p := range professions {
-			if p == d.profession {
-				hasProfession = true
-				break
-			}
-		}
-	}
+	hasProfession := eq.CanEquip(d.profession)
 	shouldEquip = shouldEquip && hasProfession
 	if shouldEquip {
-		d.Equip(eq)
+		d.inventory = append(d.inventory, d.Equip(eq)...)
 	} else {
 		//fmt.Println(d.name, "added", eq.Name(), "to inventory")
 		d.floatingText(fmt.Sprintf("+%s", eq.Name()), color.NRGBA{200, 200, 50, 128}, 100, 1.0)
@@ -761,7 +888,7 @@ func (d *Dude) Inventory() []*Equipment {
 	return d.inventory
 }
 
-func (d *Dude) Equipped() map[EquipmentType]*Equipment {
+func (d *Dude) Equipped() map[EquipmentSlot]*Equipment {
 	return d.equipped
 }
 
@@ -771,11 +898,145 @@ func (d *Dude) GetCalculatedStats() *Stats {
 	stats = stats.Add(&d.stats)
 	for _, eq := range d.equipped {
 		stats = stats.Add(eq.Stats())
+		stats = stats.Add(perkStats(eq))
 	}
+	stats = stats.Add(d.SetStats())
+	for _, t := range d.traits {
+		stats = stats.Add(t.Stats())
+	}
+	for sk, rank := range d.skills {
+		stats = stats.Add(sk.Stats(rank))
+	}
+	stats.totalHp = max(1, stats.totalHp-d.revivalPenalty)
+	stats.confidence = max(0, stats.confidence+d.taxMood)
 	stats.currentHp = d.stats.currentHp
 	return stats
 }
 
+// CanPromote returns if the dude meets its profession's promotion requirements.
+func (d *Dude) CanPromote() bool {
+	return d.profession.CanPromote(d)
+}
+
+// PromotionCost returns the gold needed to promote the dude.
+func (d *Dude) PromotionCost() int {
+	return 100 * d.Level()
+}
+
+// Promote changes the dude to its promoted profession, improving its growth and skin.
+func (d *Dude) Promote() {
+	promoted, ok := d.profession.Promotion()
+	if !ok {
+		return
+	}
+	previous := d.profession
+	d.profession = promoted
+	d.stats.levelUpChange = getLevelUpChange(promoted, d.stats.level)
+	d.SyncTint()
+	d.SyncSkin()
+	d.dirtyStats = true
+	d.floatingText("PROMOTED", color.NRGBA{255, 215, 0, 255}, 80, 1)
+	AddMessage(
+		MessageGood,
+		fmt.Sprintf("%s was promoted from %s to %s", d.name, previous.String(), promoted.String()),
+	)
+}
+
+// SyncSkin swaps the dude to the promoted skin sheet once promoted, keeping the same critter.
+func (d *Dude) SyncSkin() {
+	sheet := "dudes/liltest"
+	if d.profession.Base() != d.profession {
+		sheet = "dudes/promoted"
+	}
+	name := d.stack.StackName()
+	if err := d.stack.SetStaxie(sheet); err != nil {
+		fmt.Println("Error loading dude skin", sheet, err)
+		return
+	}
+	d.stack.SetStack(name)
+}
+
+// Traits returns the traits the dude was born with.
+func (d *Dude) Traits() []TraitKind {
+	return d.traits
+}
+
+// HasTrait returns if the dude has the trait.
+func (d *Dude) HasTrait(trait TraitKind) bool {
+	for _, t := range d.traits {
+		if t == trait {
+			return true
+		}
+	}
+	return false
+}
+
+// SkillRank returns the learned rank of the skill.
+func (d *Dude) SkillRank(sk SkillKind) int {
+	return d.skills[sk]
+}
+
+// SkillPoints returns the unspent skill points of the dude.
+func (d *Dude) SkillPoints() int {
+	return d.skillPoints
+}
+
+// CanLearnSkill returns an error if the dude cannot learn the next rank of the skill.
+func (d *Dude) CanLearnSkill(sk SkillKind) error {
+	if d.skillPoints <= 0 {
+		return ErrSkillNoPoints
+	}
+	if sk.Profession() != d.profession.Base() {
+		return ErrSkillWrongProfession
+	}
+	if d.skills[sk] >= sk.MaxRank() {
+		return ErrSkillMaxRank
+	}
+	if d.Level() < sk.RequiredLevel() {
+		return ErrSkillLevelTooLow
+	}
+	if req, ok := sk.Requires(); ok && d.skills[req] == 0 {
+		return ErrSkillMissingRequired
+	}
+	return nil
+}
+
+// LearnSkill spends a skill point on the next rank of the skill.
+func (d *Dude) LearnSkill(sk SkillKind) error {
+	if err := d.CanLearnSkill(sk); err != nil {
+		return err
+	}
+	d.skillPoints--
+	d.skills[sk]++
+	d.dirtyStats = true
+	AddMessage(
+		MessageGood,
+		fmt.Sprintf("%s learned %s rank %d", d.name, sk, d.skills[sk]),
+	)
+	return nil
+}
+
+// AutoAllocateSkills spends all skill points, preferring the lowest ranked skill deepest in the tree.
+func (d *Dude) AutoAllocateSkills() {
+	for d.skillPoints > 0 {
+		var best SkillKind
+		found := false
+		for _, sk := range GetProfessionSkills(d.profession.Base()) {
+			if d.CanLearnSkill(sk) != nil {
+				continue
+			}
+			if !found || d.skills[sk] <= d.skills[best] {
+				best = sk
+				found = true
+			}
+		}
+		if !found {
+			return
+		}
+		d.LearnSkill(best)
+	}
+}
+
 func (d *Dude) AddXP(xp int) {
 	d.xp += xp
 	// If level reached
@@ -783,6 +1044,8 @@ func (d *Dude) AddXP(xp int) {
 	if d.xp >= nextLevelXP {
 		d.xp -= nextLevelXP
 		d.stats.LevelUp(false)
+		d.skillPoints++
+		d.revivalPenalty = 0
 		d.floatingText("LEVEL UP", color.NRGBA{100, 255, 255, 255}, 80, 1)
 		AddMessage(
 			MessageGood,
@@ -809,17 +1072,22 @@ func (d *Dude) Heal(amount int) int {
 
 	initialHP := d.stats.currentHp
 	stats := d.GetCalculatedStats()
+	if d.HasTrait(TraitHardy) {
+		amount += 2
+	}
 	amount *= (stats.wisdom / 10) + 1 // Wisdom scaling
 	d.stats.currentHp += amount
 	if d.stats.currentHp > stats.totalHp {
 		d.stats.currentHp = stats.totalHp
 	}
 	amount = d.stats.currentHp - initialHP
+	d.healingDone += amount
 
 	if amount > 0 && d.story != nil {
 		d.floatingText(fmt.Sprintf("+%d", amount), color.NRGBA{0, 255, 0, 255}, 40, 0.5)
-		AddMessage(
+		AddCombatMessage(
 			MessageNeutral,
+			NewCombatEntry(d, CombatActionHeal, d.name, amount),
 			fmt.Sprintf("%s healed for %d", d.name, amount),
 		)
 		d.dirtyStats = true
@@ -855,7 +1123,7 @@ func (d *Dude) RestoreUses() {
 }
 
 func (d *Dude) RandomEquippedItem() *Equipment {
-	equippedTypes := []EquipmentType{}
+	equippedTypes := []EquipmentSlot{}
 	for t, eq := range d.equipped {
 		if eq != nil {
 			equippedTypes = append(equippedTypes, t)
@@ -901,28 +1169,30 @@ func (d *Dude) Perkify(maxQuality PerkQuality) {
 		return
 	}
 
-	// Assign random perk
-	if eq.perk == nil {
+	// Fill an empty socket with a random perk
+	if eq.FreeSockets() > 0 {
 		prevName := eq.Name()
-		eq.perk = GetRandomPerk(PerkQualityTrash)
-		//fmt.Println(d.name, "upgraded his equipment", prevName, "with", eq.perk.Name())
-		d.floatingText(fmt.Sprintf("+%s perk %s", prevName, eq.perk.Name()), color.NRGBA{128, 255, 128, 255}, 100, 0.5)
+		perk := GetRandomPerk(PerkQualityTrash)
+		eq.Socket(perk)
+		//fmt.Println(d.name, "upgraded his equipment", prevName, "with", perk.Name())
+		d.floatingText(fmt.Sprintf("+%s perk %s", prevName, perk.Name()), color.NRGBA{128, 255, 128, 255}, 100, 0.5)
 		AddMessage(
 			MessageLoot,
-			fmt.Sprintf("%s upgraded %s with %s", d.name, prevName, eq.perk.Name()),
+			fmt.Sprintf("%s upgraded %s with %s", d.name, prevName, perk.Name()),
 		)
 	} else {
-		// Level up perk
-		previousQuality := eq.perk.Quality()
-		previousName := eq.Name()
-		eq.perk.LevelUp(maxQuality)
-		if eq.perk.Quality() != previousQuality {
-			//fmt.Println(eq.perk.Quality(), previousQuality)
-			//fmt.Println(d.name, "upgraded his equipment", previousName, "to", eq.Name())
-			d.floatingText(fmt.Sprintf("+eq %s upgrade to %s", previousName, eq.Name()), color.NRGBA{128, 255, 128, 255}, 100, 0.5)
+		// Level up the weakest perk
+		perk := eq.LowestPerk().perk
+		previousQuality := perk.Quality()
+		previousName := perk.Name()
+		perk.LevelUp(maxQuality)
+		if perk.Quality() != previousQuality {
+			//fmt.Println(perk.Quality(), previousQuality)
+			//fmt.Println(d.name, "upgraded his equipment", previousName, "to", perk.Name())
+			d.floatingText(fmt.Sprintf("+eq %s upgrade to %s", previousName, perk.Name()), color.NRGBA{128, 255, 128, 255}, 100, 0.5)
 			AddMessage(
 				MessageLoot,
-				fmt.Sprintf("%s upgraded %s to %s", d.name, previousName, eq.Name()),
+				fmt.Sprintf("%s upgraded %s on %s to %s", d.name, previousName, eq.Name(), perk.Name()),
 			)
 		}
 	}
@@ -954,18 +1224,22 @@ func (d *Dude) Cursify(roomLevel int) {
 		// Spared
 		return
 	}
+	if d.UseConsumable(ConsumableAntidote) {
+		AddMessage(
+			MessageGood,
+			fmt.Sprintf("%s warded off a curse with an antidote", d.name),
+		)
+		return
+	}
 
 	// Check for gold loss
-	if curseRoll <= threshold*0.75 { // high chance for gold loss
+	robbery := 0.75
+	for _, t := range d.traits {
+		robbery *= t.RobberyMultiplier()
+	}
+	if curseRoll <= threshold*robbery { // high chance for gold loss
 		goldLoss := roomLevel * 5
 		d.Trigger(EventGoldLoss{dude: d, amount: goldLoss})
-		//fmt.Println(d.name, "lost", goldLoss, "gold")
-		d.floatingText(fmt.Sprintf("-%dgp", goldLoss), color.NRGBA{255, 255, 0, 200}, 40, 0.5)
-
-		// If gold is negative, set to 0
-		if d.gold < 0 {
-			d.gold = 0
-		}
 
 		AddMessage(
 			MessageBad,
@@ -975,8 +1249,7 @@ func (d *Dude) Cursify(roomLevel int) {
 
 	// Check for equipment delevel
 	if curseRoll <= threshold*0.5 { // reduced chance for equipment delevel
-		equipmentType := RandomEquipmentType()
-		if eq := d.equipped[equipmentType]; eq != nil {
+		if eq := d.equipped[RandomEquipmentSlot()]; eq != nil {
 			eq.LevelDown()
 			//fmt.Println(d.name, "lost a level on", eq.Name())
 			d.floatingText(fmt.Sprintf("-eq level %s", eq.Name()), color.NRGBA{200, 200, 32, 200}, 50, 0.5)
@@ -992,16 +1265,16 @@ func (d *Dude) Cursify(roomLevel int) {
 
 	// Check for perk delevel
 	if curseRoll <= threshold*0.25 { // even lower chance for perk delevel
-		equipmentWithPerks := []EquipmentType{}
+		equipmentWithPerks := []EquipmentSlot{}
 		for t, eq := range d.equipped {
-			if eq != nil && eq.perk != nil {
+			if eq != nil && eq.HasPerks() {
 				equipmentWithPerks = append(equipmentWithPerks, t)
 			}
 		}
 		if len(equipmentWithPerks) > 0 {
 			randomEquipType := equipmentWithPerks[rand.Intn(len(equipmentWithPerks))]
 			if eq := d.equipped[randomEquipType]; eq != nil {
-				eq.perk.LevelDown()
+				eq.sockets[rand.Intn(len(eq.sockets))].perk.LevelDown()
 				//fmt.Println(d.name, "lost a perk level on", eq.Name())
 				d.floatingText(fmt.Sprintf("-eq perk %s", eq.Name()), color.NRGBA{200, 200, 32, 200}, 50, 0.5)
 
@@ -1065,6 +1338,7 @@ func (d *Dude) TrapDamage(roomLevel int) Activity {
 		d.SetActivity(Ded)
 		return DudeDeadActivity{dude: d}
 	}
+	d.CheckConsumables()
 	return nil
 }
 
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"fmt"
 	"math"
 	"math/rand"
 
@@ -85,9 +86,11 @@ func (e EnemyKind) Stats() *Stats {
 const ENEMY_SCALE = 1.0
 
 type Enemy struct {
-	name  EnemyKind
-	stack *render.Stack
-	stats *Stats
+	name    EnemyKind
+	rank    EnemyRank
+	affixes []EnemyAffix
+	stack   *render.Stack
+	stats   *Stats
 }
 
 func NewEnemy(name EnemyKind, level int, stack *render.Stack) *Enemy {
@@ -112,6 +115,62 @@ func NewEnemy(name EnemyKind, level int, stack *render.Stack) *Enemy {
 	}
 }
 
+// RollRank rolls the enemy's rank and affixes for the story level and applies them.
+func (e *Enemy) RollRank(storyLevel int) {
+	e.SetRank(RollEnemyRank(storyLevel))
+}
+
+// SetRank sets the enemy's rank, rolling affixes and scaling its stats.
+func (e *Enemy) SetRank(rank EnemyRank) {
+	e.rank = rank
+	e.affixes = RollEnemyAffixes(rank.Affixes())
+	if rank == EnemyRankNormal {
+		return
+	}
+
+	multiplier := rank.StatMultiplier()
+	e.stats.strength = int(float64(e.stats.strength) * multiplier)
+	e.stats.totalHp = int(float64(e.stats.totalHp) * multiplier)
+	for _, a := range e.affixes {
+		a.Apply(e.stats)
+	}
+	e.stats.currentHp = e.stats.totalHp
+
+	// Tint the enemy by its affixes
+	if e.stack != nil {
+		e.stack.ColorScale.Reset()
+		for _, a := range e.affixes {
+			e.stack.ColorScale.ScaleWithColorScale(a.Color())
+		}
+	}
+}
+
+// HasAffix returns if the enemy carries the affix.
+func (e *Enemy) HasAffix(affix EnemyAffix) bool {
+	for _, a := range e.affixes {
+		if a == affix {
+			return true
+		}
+	}
+	return false
+}
+
+// OnHit is called with the damage the enemy actually dealt.
+func (e *Enemy) OnHit(amount int) {
+	if amount <= 0 || !e.HasAffix(EnemyAffixVampiric) {
+		return
+	}
+	e.stats.currentHp = min(e.stats.totalHp, e.stats.currentHp+amount/2)
+}
+
+// DeathDamage returns the damage dealt to the enemy's killer when it dies.
+func (e *Enemy) DeathDamage() int {
+	if !e.HasAffix(EnemyAffixExplosive) {
+		return 0
+	}
+	return e.stats.strength * 2
+}
+
 func (e *Enemy) Update(d *Dude) {
 	if e.stack == nil {
 		return
@@ -163,9 +222,21 @@ func (e *Enemy) Draw(o render.Options) {
 	e.stack.Draw(&o)
 }
 
+// Evades rolls for the enemy evading the dude's attack, logging it if so. Only swift enemies can evade.
+func (e *Enemy) Evades(d *Dude) bool {
+	if !e.HasAffix(EnemyAffixSwift) || rand.Float64() >= 0.25 {
+		return false
+	}
+	AddCombatMessage(
+		MessageNeutral,
+		NewCombatEntry(d, CombatActionMiss, e.Name(), 0),
+		fmt.Sprintf("%s evaded %s's attack", e.Name(), d.name),
+	)
+	return true
+}
+
 // Damage deals damage to the enemy, returns true if the enemy is dead.
 func (e *Enemy) Damage(amount int) bool {
-
 	// Apply defense reduction
 	reducedDamage := e.stats.ApplyDefense(amount)
 
@@ -177,18 +248,50 @@ func (e *Enemy) Hit() int {
 	return e.stats.strength
 }
 
+// Name returns the enemy's name prefixed with its rank and affixes.
 func (e *Enemy) Name() string {
-	return e.name.String()
+	name := e.name.String()
+	for i := len(e.affixes) - 1; i >= 0; i-- {
+		name = e.affixes[i].String() + " " + name
+	}
+	if e.rank != EnemyRankNormal {
+		name = e.rank.String() + " " + name
+	}
+	return name
 }
 
+// XP returns the xp reward from the enemy's loot table.
 func (e *Enemy) XP() int {
-	return min(1, e.stats.level) * 10
+	return int(float64(e.name.LootTable().XP(e.stats.level)) * e.rank.RewardMultiplier())
 }
 
-// Random gold multiplier between 0.5 and 1.25
+// Gold rolls the gold reward from the enemy's loot table.
 func (e *Enemy) Gold() int {
-	randMultiplier := 0.5 + rand.Float64()
-	return int(float64(e.stats.totalHp) * randMultiplier)
+	gold := float64(e.name.LootTable().Gold(e.stats.level)) * e.rank.RewardMultiplier()
+	if e.HasAffix(EnemyAffixGoldHoarding) {
+		gold *= 3
+	}
+	return int(gold)
+}
+
+// RollLoot rolls the equipment drops from the enemy's loot table, elites roll as if luckier.
+func (e *Enemy) RollLoot(luck int, storyLevel int) []*Equipment {
+	return e.name.LootTable().Roll(luck+int(e.rank)*25, storyLevel)
+}
+
+// Wound deals damage straight to the enemy without going through defense, leaving the killing blow to the dudes. Returns the damage dealt.
+func (e *Enemy) Wound(amount int) int {
+	amount = min(amount, e.stats.currentHp-1)
+	if amount <= 0 {
+		return 0
+	}
+	e.stats.currentHp -= amount
+	return amount
+}
+
+// IsBoss returns if the enemy is one of the story bosses.
+func (e *Enemy) IsBoss() bool {
+	return e.name >= EnemyBossRat && e.name <= EnemyBossEbi
 }
 
 func (e *Enemy) IsDead() bool {
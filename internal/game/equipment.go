@@ -80,9 +80,10 @@ type EquipmentType string
 
 // Type of equipment
 const (
-	EquipmentTypeWeapon    EquipmentType = "weapon"
-	EquipmentTypeArmor     EquipmentType = "armor"
-	EquipmentTypeAccessory EquipmentType = "accessory"
+	EquipmentTypeWeapon     EquipmentType = "weapon"
+	EquipmentTypeArmor      EquipmentType = "armor"
+	EquipmentTypeAccessory  EquipmentType = "accessory"
+	EquipmentTypeConsumable EquipmentType = "consumable"
 )
 
 func (et EquipmentType) String() string {
@@ -93,6 +94,8 @@ func (et EquipmentType) String() string {
 		return "Armor"
 	case EquipmentTypeAccessory:
 		return "Accessory"
+	case EquipmentTypeConsumable:
+		return "Consumable"
 	default:
 		return "Unknown"
 	}
@@ -108,13 +111,17 @@ type Equipment struct {
 	//material   string // Material of the equipment, maybe
 
 	name          string           // Standard name of the equipment ("Bow", "Sword", "Book", "Boots")
-	quality       EquipmentQuality // Quality of the equipment, dictates total uses
-	uses          int              // Current uses of the equipment (number of times the perk can be triggered)
-	totalUses     int              // Total uses of the equipment
+	baseName      string           // Asset name the equipment was created from ("bow", "plate")
+	uniqueName    string           // Unique name of the equipment, replaces name when displayed
+	unique        *UniqueItem      // Catalog entry if the equipment is a hand-made unique
+	quality       EquipmentQuality // Quality of the equipment, dictates sockets and perk uses
 	description   string           // Description of the equipment
 	equipmentType EquipmentType    // Type of equipment (weapon, armor, etc.)
+	slots         []EquipmentSlot  // Slots the equipment can be worn in
+	twoHanded     bool             // Whether the equipment takes up the off hand too
+	consumable    ConsumableKind   // What the equipment does when used, if it's a consumable
 
-	perk        IPerk            // Perk of the equipment (if any)
+	sockets     []*PerkSocket    // Perks of the equipment (if any)
 	stats       *Stats           // Stats of the equipment (if any)
 	stack       *render.Stack    // How to draw the equipment
 	professions []ProfessionKind // If restricted to a profession
@@ -125,6 +132,9 @@ func EquipmentLevelSort(i, j *Equipment) bool {
 	a := i.LevelWithQuality()
 	b := j.LevelWithQuality()
 	if a == b {
+		if i.PerkScore() != j.PerkScore() {
+			return i.PerkScore() < j.PerkScore()
+		}
 		return i.name < j.name
 	}
 	return a < b
@@ -141,8 +151,16 @@ func NewEquipment(name string, level int, quality EquipmentQuality, perk IPerk)
 	}
 
 	// Parse equipment asset to equipment
-	baseStackName := fmt.Sprintf("equipment/%s", baseEquipment.Type)
-	stack, err := render.NewStack(baseStackName, baseEquipment.BaseName, "")
+	sheet := baseEquipment.Type
+	if baseEquipment.Sheet != "" {
+		sheet = baseEquipment.Sheet
+	}
+	baseStackName := fmt.Sprintf("equipment/%s", sheet)
+	stackName := baseEquipment.BaseName
+	if baseEquipment.Stack != "" {
+		stackName = baseEquipment.Stack
+	}
+	stack, err := render.NewStack(baseStackName, stackName, "")
 	if err != nil {
 		fmt.Println("Error loading equipment stack for: ", baseEquipment.BaseName, err)
 		stack = nil
@@ -157,22 +175,20 @@ func NewEquipment(name string, level int, quality EquipmentQuality, perk IPerk)
 
 	// If base equipment has perk, load it
 	// hmmm...
-	if perk == nil {
-		switch baseEquipment.Perk {
-		case "Heal On Room Enter":
-			perk = PerkHealOnRoomEnter{&Perk{quality: PerkQualityCommon}}
-		}
+	if perk == nil && baseEquipment.Perk != "" {
+		perk = GetPerkByName(baseEquipment.Perk, "", PerkQualityCommon)
 	}
 
 	equipment := &Equipment{
 		name:          baseEquipment.Name,
+		baseName:      baseEquipment.BaseName,
 		quality:       quality,
-		uses:          int(quality) + 1,
-		totalUses:     int(quality) + 1,
 		description:   baseEquipment.Description,
 		equipmentType: EquipmentType(baseEquipment.Type),
+		slots:         EquipmentSlots(baseEquipment.Slot, EquipmentType(baseEquipment.Type), baseEquipment.TwoHanded),
+		twoHanded:     baseEquipment.TwoHanded,
+		consumable:    ConsumableKind(baseEquipment.Consumable),
 		professions:   professions,
-		perk:          perk,
 		stack:         stack,
 		stats: &Stats{
 			levelUpChange: &Stats{
@@ -187,14 +203,19 @@ func NewEquipment(name string, level int, quality EquipmentQuality, perk IPerk)
 		},
 	}
 
+	equipment.Socket(perk)
+
 	for i := 0; i < level; i++ {
 		equipment.LevelUp(EquipmentQualityLegendary)
 	}
 
 	equipment.Draw = func(o *render.Options) {
 		if equipment.stack != nil {
-			// Based on quality, modify color slightly
+			// Based on quality, modify color slightly, uniques bring their own tint
 			cs := quality.Color()
+			if equipment.unique != nil && equipment.unique.tint != nil {
+				cs = *equipment.unique.tint
+			}
 
 			// Reset colors.
 			o.DrawImageOptions.ColorScale.Reset()
@@ -212,18 +233,31 @@ func (e *Equipment) Update() {
 	e.stack.Update()
 }
 
-// Name returns the full name of the equipment including perk.
+// Name returns the full name of the equipment including perks.
 func (e *Equipment) FullName() string {
 	perkName := ""
-	if e.perk != nil {
-		perkName = " of " + e.perk.Name()
+	if e.HasPerks() {
+		perkName = " of " + e.PerkNames()
 	}
-	return fmt.Sprintf("%s %s%s", e.quality, e.name, perkName)
+	return fmt.Sprintf("%s %s%s", e.quality, e.DisplayName(), perkName)
 }
 
 // Name returns the name of the equipment with quality
 func (e *Equipment) Name() string {
-	return fmt.Sprintf("%s %s", e.quality, e.name)
+	return fmt.Sprintf("%s %s", e.quality, e.DisplayName())
+}
+
+// BaseName returns the asset name the equipment can be recreated from.
+func (e *Equipment) BaseName() string {
+	return e.baseName
+}
+
+// DisplayName returns the unique name of the equipment if it has one, otherwise the standard name.
+func (e *Equipment) DisplayName() string {
+	if e.uniqueName != "" {
+		return e.uniqueName
+	}
+	return e.name
 }
 
 // Level returns the level of the equipment.
@@ -245,12 +279,14 @@ func (e *Equipment) ChangeQuality(delta int) {
 		}
 	}
 
-	e.uses += delta * 2
-	if e.uses > e.totalUses {
-		e.uses = e.totalUses
-	}
-	if e.uses < 0 {
-		e.uses = 0
+	for _, ps := range e.sockets {
+		ps.uses += delta * 2
+		if ps.uses > ps.totalUses {
+			ps.uses = ps.totalUses
+		}
+		if ps.uses < 0 {
+			ps.uses = 0
+		}
 	}
 
 	// If we're trying to decrease the quality of a common item, don't
@@ -265,13 +301,15 @@ func (e *Equipment) ChangeQuality(delta int) {
 
 	// Here we are always 1 or -1 delta
 	e.quality = EquipmentQuality(int(e.quality) + delta)
-	e.totalUses += delta * 2
-	if e.totalUses < 0 {
-		e.totalUses = 0
-	}
-	// If we increased total use, increase uses
-	if delta > 0 {
-		e.uses += delta * 2
+	for _, ps := range e.sockets {
+		ps.totalUses += delta * 2
+		if ps.totalUses < 0 {
+			ps.totalUses = 0
+		}
+		// If we increased total use, increase uses
+		if delta > 0 {
+			ps.uses += delta * 2
+		}
 	}
 
 }
@@ -318,9 +356,13 @@ func (e *Equipment) Quality() EquipmentQuality {
 	return e.quality
 }
 
-// Uses returns the uses of the equipment.
+// Uses returns the uses left across all of the equipment's perks.
 func (e *Equipment) Uses() int {
-	return e.uses
+	uses := 0
+	for _, ps := range e.sockets {
+		uses += ps.uses
+	}
+	return uses
 }
 
 // Description returns the description of the equipment.
@@ -334,22 +376,27 @@ func (e *Equipment) Description() string {
 // 	return e.perk
 // }
 
-// Activate the equipment's perk and decrement the uses.
+// Activate the equipment's perks and decrement the uses of each that triggers.
 func (e *Equipment) Activate(event Event) {
-	if e.perk == nil || e.uses == 0 {
-		return
-	}
+	for _, ps := range e.sockets {
+		if ps.uses == 0 {
+			continue
+		}
 
-	activated := e.perk.Check(event)
-	if !activated {
-		return
-	}
+		// Let the combat log know which perk any resulting entries come from.
+		combatLog.source = ps.perk.Name()
+		activated := ps.perk.Check(event)
+		combatLog.source = ""
+		if !activated {
+			continue
+		}
 
-	// Successfully activated the perk, decrement the uses
-	e.uses--
-	if e.uses < 0 {
-		// Get ye gone!
-		e.uses = 0
+		// Successfully activated the perk, decrement the uses
+		ps.uses--
+		if ps.uses < 0 {
+			// Get ye gone!
+			ps.uses = 0
+		}
 	}
 }
 
@@ -383,14 +430,14 @@ func (e *Equipment) Stats() *Stats {
 }
 
 func (e *Equipment) CanEquip(p ProfessionKind) bool {
-	if e.professions == nil {
+	if len(e.professions) == 0 {
 		return true
 	}
 
-	// If the profession is in the list of professions that can equip this item
-	// then we can equip it
+	// If the profession (or the one it was promoted from) is in the list of
+	// professions that can equip this item then we can equip it
 	for _, prof := range e.professions {
-		if prof == p {
+		if p.Is(prof) {
 			return true
 		}
 	}
@@ -402,16 +449,24 @@ func (e *Equipment) Type() EquipmentType {
 	return e.equipmentType
 }
 
+// GoldValue returns what the equipment sells for, from its level, quality and perks.
 func (e *Equipment) GoldValue() int {
-	return (e.stats.level + 5*int(e.quality)) * 10
+	if e.IsConsumable() {
+		return e.consumable.Value()
+	}
+	return (e.stats.level+5*int(e.quality))*10 + e.PerkScore()*5
 }
 
+// RestoreUses restores the uses of all the equipment's perks, returning if any were restored.
 func (e *Equipment) RestoreUses() bool {
-	if e.uses == e.totalUses {
-		return false
+	restored := false
+	for _, ps := range e.sockets {
+		if ps.uses != ps.totalUses {
+			ps.uses = ps.totalUses
+			restored = true
+		}
 	}
-	e.uses = e.totalUses
-	return true
+	return restored
 }
 
 func (e *Equipment) LevelWithQuality() int {
@@ -444,14 +499,14 @@ func GetRandomEquipment(level int) *Equipment {
 	// Lower chance to get higher quality equipment
 	qualityroll := rand.Intn(50)
 	quality := EquipmentQualityCommon
-	if qualityroll < 10 {
-		quality = EquipmentQualityUncommon
-	} else if qualityroll < 5 {
-		quality = EquipmentQualityRare
+	if qualityroll < 1 {
+		quality = EquipmentQualityLegendary
 	} else if qualityroll < 2 {
 		quality = EquipmentQualityEpic
-	} else if qualityroll < 1 {
-		quality = EquipmentQualityLegendary
+	} else if qualityroll < 5 {
+		quality = EquipmentQualityRare
+	} else if qualityroll < 10 {
+		quality = EquipmentQualityUncommon
 	}
 
 	// Random perk
@@ -503,7 +558,7 @@ func SortEquipment(sp SortProperty, equipment []*Equipment) []*Equipment {
 		return iIndex < jIndex
 	}
 
-	typeOrder := []EquipmentType{EquipmentTypeWeapon, EquipmentTypeArmor, EquipmentTypeAccessory}
+	typeOrder := []EquipmentType{EquipmentTypeWeapon, EquipmentTypeArmor, EquipmentTypeAccessory, EquipmentTypeConsumable}
 	typeSort := func(i, j int) bool {
 		iIndex := -1
 		jIndex := -1
@@ -525,6 +580,16 @@ func SortEquipment(sp SortProperty, equipment []*Equipment) []*Equipment {
 		return EquipmentLevelSort(equipment[i], equipment[j])
 	}
 
+	// Most perks first, weighed by their quality
+	perkSort := func(i, j int) bool {
+		a := equipment[i].PerkScore()
+		b := equipment[j].PerkScore()
+		if a == b {
+			return nameSort(i, j)
+		}
+		return a > b
+	}
+
 	var sortMethod func(i, j int) bool = nameSort
 	switch sp {
 	case SortPropertyProfession:
@@ -533,6 +598,8 @@ func SortEquipment(sp SortProperty, equipment []*Equipment) []*Equipment {
 		sortMethod = levelSort
 	case SortPropertyType:
 		sortMethod = typeSort
+	case SortPropertyPerks:
+		sortMethod = perkSort
 	case SortPropertyName:
 	default:
 	}
@@ -187,3 +187,13 @@ type EventDudeDodge struct {
 func (e EventDudeDodge) String() string {
 	return "Dude Dodge"
 }
+
+// EventConsumableUse occurs when a dude uses a consumable from their belt
+type EventConsumableUse struct {
+	dude       *Dude
+	consumable *Equipment
+}
+
+func (e EventConsumableUse) String() string {
+	return "Consumable Use"
+}
@@ -0,0 +1,149 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+)
+
+const (
+	ForgeCount     = 3 // Items of the same name and quality it takes to forge one of the next quality.
+	ForgeMaxGroups = 6 // Most forge recipes shown at once.
+)
+
+// ForgeGroup is a set of stashed items that can be forged together.
+type ForgeGroup struct {
+	name     string
+	baseName string
+	quality  EquipmentQuality
+	items    []*Equipment // Best perks first.
+}
+
+// ForgeGroups groups the equipment by base name and quality, returning the groups with enough items to forge.
+func ForgeGroups(equipment []*Equipment) []ForgeGroup {
+	type key struct {
+		name    string
+		quality EquipmentQuality
+	}
+	groups := make(map[key]*ForgeGroup)
+	var keys []key
+	for _, e := range equipment {
+		if e.quality >= EquipmentQualityLegendary || e.IsConsumable() || e.IsUnique() {
+			continue
+		}
+		k := key{e.baseName, e.quality}
+		if _, ok := groups[k]; !ok {
+			groups[k] = &ForgeGroup{name: e.name, baseName: e.baseName, quality: e.quality}
+			keys = append(keys, k)
+		}
+		groups[k].items = append(groups[k].items, e)
+	}
+
+	var result []ForgeGroup
+	for _, k := range keys {
+		fg := groups[k]
+		if len(fg.items) < ForgeCount {
+			continue
+		}
+		sort.SliceStable(fg.items, func(i, j int) bool {
+			return fg.items[i].PerkScore() > fg.items[j].PerkScore()
+		})
+		result = append(result, *fg)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].name == result[j].name {
+			return result[i].quality < result[j].quality
+		}
+		return result[i].name < result[j].name
+	})
+	return result
+}
+
+// Inputs returns the items that forging the group uses up.
+func (fg ForgeGroup) Inputs() []*Equipment {
+	return fg.items[:ForgeCount]
+}
+
+// Level returns the level the forged item keeps, the best of the inputs.
+func (fg ForgeGroup) Level() int {
+	level := 0
+	for _, e := range fg.Inputs() {
+		level = max(level, e.Level())
+	}
+	return level
+}
+
+// Perks returns the perks of the input with the best perks, or nil if a new one will be rolled.
+func (fg ForgeGroup) Perks() []IPerk {
+	return fg.Inputs()[0].Perks()
+}
+
+// MaterialCost returns the materials it takes to forge the group.
+func (fg ForgeGroup) MaterialCost() int {
+	return 2 * (int(fg.quality) + 1)
+}
+
+// Preview describes what forging the group produces.
+func (fg ForgeGroup) Preview() string {
+	next := fg.quality + 1
+	perk := "rolls a new perk"
+	if fg.Inputs()[0].HasPerks() {
+		perk = "keeps " + fg.Inputs()[0].PerkNames()
+	}
+	return fmt.Sprintf("%dx %s %s -> %s %s lvl %d\n%s, %d materials", ForgeCount, fg.quality, fg.name, next, fg.name, fg.Level(), perk, fg.MaterialCost())
+}
+
+// Forge turns the group's inputs into one item of the next quality.
+func (fg ForgeGroup) Forge() *Equipment {
+	perks := fg.Perks()
+	if len(perks) == 0 {
+		perks = []IPerk{GetRandomPerk(ForgePerkQuality(fg.quality + 1))}
+	}
+	e := NewEquipment(fg.baseName, fg.Level(), fg.quality+1, perks[0])
+	if e == nil {
+		return nil
+	}
+	for _, p := range perks[1:] {
+		e.Socket(p)
+	}
+	return e
+}
+
+// ForgePerkQuality returns the quality of perks rolled for equipment of the given quality.
+func ForgePerkQuality(quality EquipmentQuality) PerkQuality {
+	return min(PerkQuality(quality)+PerkQualityPathetic, PerkQualityGodly)
+}
+
+// SalvageValue returns the materials salvaging the equipment gives.
+func (e *Equipment) SalvageValue() int {
+	return 1 + int(e.quality) + e.Level()/2
+}
+
+// ReforgeCost returns the gold it takes to reroll the equipment's perks.
+func (e *Equipment) ReforgeCost() int {
+	return 25 * (int(e.quality) + 1) * (e.PerkScore() + 1)
+}
+
+// Reforge rerolls each of the equipment's perks keeping their qualities, or adds one if it has none.
+func (e *Equipment) Reforge() {
+	if !e.HasPerks() {
+		e.Socket(GetRandomPerk(ForgePerkQuality(e.quality)))
+		return
+	}
+	for _, ps := range e.sockets {
+		ps.perk = GetRandomPerk(ps.perk.Quality())
+	}
+}
+
+// SalvagePreview describes what salvaging the equipment gives.
+func (e *Equipment) SalvagePreview() string {
+	return fmt.Sprintf("Salvage %s\n+%d materials", e.Name(), e.SalvageValue())
+}
+
+// ReforgePreview describes what reforging the equipment does.
+func (e *Equipment) ReforgePreview() string {
+	if !e.HasPerks() {
+		q := ForgePerkQuality(e.quality)
+		return fmt.Sprintf("Reforge %s\nadds a %s perk, %dgp", e.Name(), q.String(), e.ReforgeCost())
+	}
+	return fmt.Sprintf("Reforge %s\nrerolls %s, %dgp", e.Name(), e.PerkNames(), e.ReforgeCost())
+}
@@ -15,6 +15,7 @@ import (
 type Game struct {
 	ui                    *UI
 	dudes                 []*Dude
+	fallen                []*Dude // Dudes that died and can be revived between runs.
 	renderables           []render.Renderable
 	camera                render.Camera
 	mouseX, mouseY        int
@@ -30,6 +31,11 @@ type Game struct {
 	paused                bool
 	speed                 int
 	gold                  int
+	taxRate               float64
+	treasury              *Treasury
+	parties               []*Party
+	materials             int // Salvaged materials used for forging.
+	shop                  []*ShopItem
 	equipment             []*Equipment
 	autoplay              bool
 	simMode               bool
@@ -82,51 +88,55 @@ func (g *Game) Update() error {
 		g.camera.Pitch = 0
 	}*/
 
-	if ebiten.IsKeyPressed(ebiten.KeyQ) || ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
-		g.camera.SetRotationAt(g.camera.Rotation()-0.05, 1)
-		g.selectedDude = nil
-	} else if ebiten.IsKeyPressed(ebiten.KeyE) || ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
-		g.camera.SetRotationAt(g.camera.Rotation()+0.05, 1)
-		g.selectedDude = nil
-	}
+	// Keys are typed into a party name instead while one is being edited.
+	if !g.ui.partyPanel.Editing() {
+		if ebiten.IsKeyPressed(ebiten.KeyQ) || ebiten.IsKeyPressed(ebiten.KeyLeft) || ebiten.IsKeyPressed(ebiten.KeyA) {
+			g.camera.SetRotationAt(g.camera.Rotation()-0.05, 1)
+			g.selectedDude = nil
+		} else if ebiten.IsKeyPressed(ebiten.KeyE) || ebiten.IsKeyPressed(ebiten.KeyRight) || ebiten.IsKeyPressed(ebiten.KeyD) {
+			g.camera.SetRotationAt(g.camera.Rotation()+0.05, 1)
+			g.selectedDude = nil
+		}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyW) || inpututil.IsKeyJustPressed(ebiten.KeyUp) {
-		if g.tower != nil && g.camera.Story() < len(g.tower.Stories)-2 {
-			g.camera.SetStory(g.camera.Story() + 1)
+		if inpututil.IsKeyJustPressed(ebiten.KeyW) || inpututil.IsKeyJustPressed(ebiten.KeyUp) {
+			if g.tower != nil && g.camera.Story() < len(g.tower.Stories)-2 {
+				g.camera.SetStory(g.camera.Story() + 1)
+				g.selectedDude = nil
+			}
+		} else if inpututil.IsKeyJustPressed(ebiten.KeyS) || inpututil.IsKeyJustPressed(ebiten.KeyDown) {
+			g.camera.SetStory(g.camera.Story() - 1)
 			g.selectedDude = nil
 		}
-	} else if inpututil.IsKeyJustPressed(ebiten.KeyS) || inpututil.IsKeyJustPressed(ebiten.KeyDown) {
-		g.camera.SetStory(g.camera.Story() - 1)
-		g.selectedDude = nil
-	}
 
-	if inpututil.IsKeyJustPressed(ebiten.Key1) {
-		g.camera.SetMode(render.CameraModeTower)
-		g.ui.speedPanel.cameraButton.SetImage("tower")
-		g.ui.speedPanel.cameraButton.tooltip = "camera: tower"
-	} else if inpututil.IsKeyJustPressed(ebiten.Key2) {
-		g.camera.SetMode(render.CameraModeStack)
-		g.ui.speedPanel.cameraButton.SetImage("story")
-		g.ui.speedPanel.cameraButton.tooltip = "camera: story"
-	} else if inpututil.IsKeyJustPressed(ebiten.Key3) {
-		g.camera.SetMode(render.CameraModeSuperZoom)
-		g.ui.speedPanel.cameraButton.SetImage("room")
-		g.ui.speedPanel.cameraButton.tooltip = "camera: room"
-	}
+		if inpututil.IsKeyJustPressed(ebiten.Key1) {
+			g.camera.SetMode(render.CameraModeTower)
+			g.ui.speedPanel.cameraButton.SetImage("tower")
+			g.ui.speedPanel.cameraButton.tooltip = "camera: tower"
+		} else if inpututil.IsKeyJustPressed(ebiten.Key2) {
+			g.camera.SetMode(render.CameraModeStack)
+			g.ui.speedPanel.cameraButton.SetImage("story")
+			g.ui.speedPanel.cameraButton.tooltip = "camera: story"
+		} else if inpututil.IsKeyJustPressed(ebiten.Key3) {
+			g.camera.SetMode(render.CameraModeSuperZoom)
+			g.ui.speedPanel.cameraButton.SetImage("room")
+			g.ui.speedPanel.cameraButton.tooltip = "camera: room"
+		}
 
-	if ebiten.IsKeyPressed(ebiten.KeyZ) {
-		g.camera.ZoomIn()
-	} else if ebiten.IsKeyPressed(ebiten.KeyX) {
-		g.camera.ZoomOut()
-	}
+		if ebiten.IsKeyPressed(ebiten.KeyZ) {
+			g.camera.ZoomIn()
+		} else if ebiten.IsKeyPressed(ebiten.KeyX) {
+			g.camera.ZoomOut()
+		}
 
-	if inpututil.IsKeyJustPressed(ebiten.KeyF11) || inpututil.IsKeyJustPressed(ebiten.KeyF) {
-		ebiten.SetFullscreen(!ebiten.IsFullscreen())
+		if inpututil.IsKeyJustPressed(ebiten.KeyF11) || inpututil.IsKeyJustPressed(ebiten.KeyF) {
+			ebiten.SetFullscreen(!ebiten.IsFullscreen())
+		}
 	}
 
 	// FIXME: For some reason Layout doesn't position the UI properly...
 	g.ui.Layout(&g.uiOptions)
 
+	g.ui.messagePanel.dude = g.selectedDude
 	g.ui.Update(&g.uiOptions)
 
 	if nextState := g.state.Update(g); nextState != nil {
@@ -271,6 +281,8 @@ func (g *Game) ToggleEnableUI(enable bool) {
 		g.ui.equipmentPanel.buyButton,
 		g.ui.equipmentPanel.sortButton,
 		g.ui.equipmentPanel.sellAllButton,
+		g.ui.treasuryPanel.lowerButton,
+		g.ui.treasuryPanel.raiseButton,
 	}
 	for _, e := range elements {
 		if enable {
@@ -320,14 +332,30 @@ func (g *Game) UpdateInfo() {
 	g.ui.gameInfoPanel.dudeText.SetText(fmt.Sprintf("Dudes: %d", len(aliveDudes)))
 	// Move this if it's too heavy.
 	g.ui.dudePanel.SetDudes(aliveDudes)
+	g.SyncParties()
 }
 
 func (g *Game) Init() {
 	// Init the equipment
 	assets.LoadEquipment()
+	LoadEquipmentSets()
+	LoadUniqueItems()
+
+	// Load meta-progression, the game works fine without it.
+	if err := LoadProfile(); err != nil {
+		fmt.Println("Error loading profile", err)
+	}
 
 	g.ui = NewUI()
 	g.uiOptions = UIOptions{Scale: 2.0}
+	g.ui.messagePanel.onExportClick = func() {
+		name, err := ExportCombatLog()
+		if err != nil {
+			g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("failed to export combat log: %s", err))
+			return
+		}
+		g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("combat log exported to %s.csv and %s.json", name, name))
+	}
 	g.ui.speedPanel.pauseButton.onCheck = func(kind UICheckKind) {
 		if kind == UICheckClick {
 			g.TogglePause()
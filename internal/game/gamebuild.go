@@ -3,6 +3,7 @@ package game
 import (
 	"fmt"
 	"math"
+	"slices"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -21,8 +22,11 @@ type GameStateBuild struct {
 	placingRoom      *RoomDef
 	placingIndex     int
 	readyAttempts    int
+	candidates       []*Candidate
 	//
 	selectedEquipment int
+	forgeGroups       []ForgeGroup
+	autoEquipPending  bool // Whether the auto-equip preview is waiting to be confirmed.
 	shownBossWarning  bool
 }
 
@@ -82,62 +86,48 @@ func (s *GameStateBuild) Begin(g *Game) {
 	}
 
 	g.ui.equipmentPanel.onBuyClick = func() {
-		s.BuyEquipment(g)
+		g.ui.shopPanel.hidden = !g.ui.shopPanel.hidden
 	}
 
-	g.ui.equipmentPanel.onSortClick = func(sp SortProperty) {
-		if g.equipment == nil || len(g.equipment) == 0 {
+	g.ui.dudeInfoPanel.onPromoteClick = func() {
+		if g.selectedDude == nil {
 			return
 		}
-		g.equipment = SortEquipment(sp, g.equipment)
-		g.ui.equipmentPanel.SetEquipment(g.equipment)
+		s.PromoteDude(g, g.selectedDude)
 	}
-	g.ui.equipmentPanel.onAutoEquipClick = func() {
-		// Sort equipment by level, then iterate through dudes and attempt to equip.
-		if g.equipment == nil || len(g.equipment) == 0 {
+
+	g.ui.dudeInfoPanel.onSkillClick = func(sk SkillKind) {
+		if g.selectedDude == nil {
+			g.ui.feedback.Msg(FeedbackBad, "select a dude to teach skills to!")
 			return
 		}
-		// Sort equipment by level
-		equips := SortEquipment(SortPropertyLevel, g.equipment)
-
-		newList := make([]*Equipment, 0)
-		afterAuto := make([]*Equipment, len(equips))
-		copy(afterAuto, equips)
-
-		// Equip highest level dudes first
-		sorted := SortDudes(SortPropertyLevel, g.dudes)
-		equipped := 0
-		for _, d := range sorted {
-			for _, e := range afterAuto {
-				if d.ShouldEquip(e) {
-					old := d.Equip(e)
-					equipped++
-					if old != nil {
-						newList = append(newList, old)
-					}
-				} else {
-					newList = append(newList, e)
-				}
-			}
-			afterAuto = make([]*Equipment, len(newList))
-			copy(afterAuto, newList)
-			newList = make([]*Equipment, 0)
+		if err := g.selectedDude.LearnSkill(sk); err != nil {
+			g.ui.feedback.Msg(FeedbackBad, err.Error())
+			return
 		}
+		g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("%s learned %s", g.selectedDude.Name(), sk))
+	}
 
-		if equipped > 0 {
-			g.equipment = afterAuto
-			g.ui.equipmentPanel.SetEquipment(afterAuto)
+	g.ui.equipmentPanel.onSortClick = func(sp SortProperty) {
+		if g.equipment == nil || len(g.equipment) == 0 {
+			return
 		}
+		g.equipment = SortEquipment(sp, g.equipment)
+		g.ui.equipmentPanel.SetEquipment(g.equipment)
+	}
+	g.ui.equipmentPanel.onAutoEquipClick = func() {
+		s.AutoEquip(g)
 	}
 	g.ui.equipmentPanel.onSellAllClick = func() {
 		s.SellAllEquipment(g)
 	}
-	g.ui.equipmentPanel.buyButton.text.SetText(fmt.Sprintf("Buy Loot\n%dgp", s.EquipmentCost()))
 	g.ui.equipmentPanel.onItemClick = func(which int) {
 		g.ui.equipmentPanel.list.selected = which
 		s.selectedEquipment = which
+		s.SyncAutoEquip(g, false)
 		g.ui.equipmentPanel.details.SetEquipment(g.equipment[which])
 		g.ui.equipmentPanel.showDetails = true
+		s.SyncForge(g)
 	}
 	g.ui.equipmentPanel.details.onSellClick = func(e *Equipment) {
 		if e == nil {
@@ -162,7 +152,6 @@ func (s *GameStateBuild) Begin(g *Game) {
 			g.ui.feedback.Msg(FeedbackBad, "select a dude to swap equipment with!")
 			return
 		}
-		equipType := e.Type()
 		profession := g.selectedDude.profession
 
 		// Check if the dude can equip the item.
@@ -171,20 +160,18 @@ func (s *GameStateBuild) Begin(g *Game) {
 			return
 		}
 
-		// Unequip the dude's current equipment.
-		if g.selectedDude.equipped[equipType] != nil {
-			unequipped := g.selectedDude.Unequip(equipType)
-			if unequipped != nil {
-				// Add to game equipment.
-				g.equipment = append(g.equipment, unequipped)
-
-				g.equipment = SortEquipment(g.ui.equipmentPanel.sortMethod, g.equipment)
-				g.ui.equipmentPanel.SetEquipment(g.equipment)
-			}
+		if e.IsConsumable() && g.selectedDude.BeltFull() {
+			g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("%s's belt is full", g.selectedDude.Name()))
+			return
 		}
 
-		// Equip the new item.
-		g.selectedDude.Equip(e)
+		// Equip the new item, returning whatever it replaced to the game equipment.
+		if unequipped := g.selectedDude.Equip(e); len(unequipped) > 0 {
+			g.equipment = append(g.equipment, unequipped...)
+
+			g.equipment = SortEquipment(g.ui.equipmentPanel.sortMethod, g.equipment)
+			g.ui.equipmentPanel.SetEquipment(g.equipment)
+		}
 		// Remove from game equipment.
 		for i, eq := range g.equipment {
 			if eq == e {
@@ -214,8 +201,7 @@ func (s *GameStateBuild) Begin(g *Game) {
 		if e == nil {
 			return
 		}
-		equipmentType := e.Type()
-		item := g.selectedDude.Unequip(equipmentType)
+		item := g.selectedDude.Unequip(e)
 
 		if item == nil {
 			return
@@ -232,8 +218,7 @@ func (s *GameStateBuild) Begin(g *Game) {
 		if e == nil || g.selectedDude == nil {
 			return
 		}
-		equipType := e.Type()
-		item := g.selectedDude.Unequip(equipType)
+		item := g.selectedDude.Unequip(e)
 		if item != nil {
 			g.equipment = append(g.equipment, item)
 			g.ui.equipmentPanel.SetEquipment(g.equipment)
@@ -244,13 +229,87 @@ func (s *GameStateBuild) Begin(g *Game) {
 		g.UpdateInfo()
 	}
 
+	// New faces at the tavern every story.
+	s.candidates = RollCandidates(g.dudes)
 	g.ui.dudePanel.onBuyClick = func() {
-		s.BuyDude(g)
+		g.ui.tavernPanel.hidden = !g.ui.tavernPanel.hidden
 	}
 	g.ui.dudePanel.onFillClick = func() {
 		s.FillDudes(g)
 	}
-	g.ui.dudePanel.buyButton.text.SetText(fmt.Sprintf("Hire Dude\n%dgp", s.DudeCost(len(g.dudes))))
+	g.ui.tavernPanel.onHireClick = func(which int) {
+		s.HireCandidate(g, which)
+	}
+	g.ui.tavernPanel.onRefreshClick = func() {
+		s.RefreshTavern(g)
+	}
+	s.SyncTavern(g)
+
+	// Fresh stock at the shop every story, less whatever was reserved.
+	g.shop = RestockShop(g.shop, s.nextStory.level)
+	g.ui.shopPanel.onBuyClick = func(which int) {
+		s.BuyShopItem(g, which)
+	}
+	g.ui.shopPanel.onReserveClick = func(which int) {
+		s.ReserveShopItem(g, which)
+	}
+	g.ui.shopPanel.onRefreshClick = func() {
+		s.RefreshShop(g)
+	}
+	s.SyncShop(g)
+	g.ui.treasuryPanel.onLowerClick = func() {
+		s.ChangeTaxRate(g, -TaxRateStep)
+	}
+	g.ui.treasuryPanel.onRaiseClick = func() {
+		s.ChangeTaxRate(g, TaxRateStep)
+	}
+	g.ui.dudePanel.onReviveClick = func() {
+		s.ReviveDude(g)
+	}
+	g.ui.forgePanel.onToggle = func() {
+		s.SyncForge(g)
+	}
+	g.ui.forgePanel.onForgeClick = func(index int) {
+		s.ForgeEquipment(g, index)
+	}
+	g.ui.forgePanel.onSalvageClick = func() {
+		s.SalvageEquipment(g)
+	}
+	g.ui.forgePanel.onReforgeClick = func() {
+		s.ReforgeEquipment(g)
+	}
+	g.ui.lootRulesPanel.onToggle = func() {
+		s.SyncLootRules(g)
+	}
+	g.ui.lootRulesPanel.onSpreadClick = func() {
+		GetProfile().Loot.Spread = !GetProfile().Loot.Spread
+		s.SyncLootRules(g)
+	}
+	g.ui.lootRulesPanel.onSellClick = func() {
+		GetProfile().Loot.CycleMaxSellQuality()
+		s.SyncLootRules(g)
+	}
+	g.ui.lootRulesPanel.onPriorityClick = func(pk ProfessionKind, stat Stat) {
+		GetProfile().Loot.TogglePriority(pk, stat)
+		s.SyncLootRules(g)
+	}
+	g.ui.lootRulesPanel.onKeepClick = func(name string) {
+		GetProfile().Loot.ToggleKeepPerk(name)
+		s.SyncLootRules(g)
+	}
+	g.ui.dudeInfoPanel.onPartyClick = func() {
+		s.CycleParty(g)
+	}
+	g.ui.partyPanel.onDepartureClick = func(index int) {
+		g.parties[index].CycleDeparture(index, len(g.parties))
+		g.SyncParties()
+	}
+	g.ui.partyPanel.onRename = func(index int, name string) {
+		g.parties[index].name = name
+		g.SyncParties()
+	}
+	s.SyncReviveButton(g)
+	s.SyncForge(g)
 
 	g.ui.roomPanel.buyButton.onClick = func() {
 		s.RerollRooms(g)
@@ -302,7 +361,38 @@ func (s *GameStateBuild) Begin(g *Game) {
 	g.UpdateInfo()
 }
 func (s *GameStateBuild) End(g *Game) {
+	g.ui.equipmentPanel.details.SetComparison(nil, nil)
+	s.SyncAutoEquip(g, false)
 	g.ui.roomPanel.onItemClick = nil
+	g.ui.dudeInfoPanel.onSkillClick = nil
+	g.ui.dudeInfoPanel.onPromoteClick = nil
+	g.ui.dudePanel.onReviveClick = nil
+	g.ui.dudeInfoPanel.onPartyClick = nil
+	g.ui.partyPanel.StopEditing()
+	g.ui.partyPanel.onDepartureClick = nil
+	g.ui.partyPanel.onRename = nil
+	g.ui.partyPanel.hidden = true
+	g.ui.forgePanel.onToggle = nil
+	g.ui.forgePanel.onForgeClick = nil
+	g.ui.forgePanel.onSalvageClick = nil
+	g.ui.forgePanel.onReforgeClick = nil
+	g.ui.forgePanel.hidden = true
+	g.ui.lootRulesPanel.onToggle = nil
+	g.ui.lootRulesPanel.onSpreadClick = nil
+	g.ui.lootRulesPanel.onSellClick = nil
+	g.ui.lootRulesPanel.onPriorityClick = nil
+	g.ui.lootRulesPanel.onKeepClick = nil
+	g.ui.lootRulesPanel.hidden = true
+	g.ui.dudePanel.reviveButton.hidden = true
+	g.ui.tavernPanel.onHireClick = nil
+	g.ui.tavernPanel.onRefreshClick = nil
+	g.ui.tavernPanel.hidden = true
+	g.ui.shopPanel.onBuyClick = nil
+	g.ui.shopPanel.onReserveClick = nil
+	g.ui.shopPanel.onRefreshClick = nil
+	g.ui.shopPanel.hidden = true
+	g.ui.treasuryPanel.onLowerClick = nil
+	g.ui.treasuryPanel.onRaiseClick = nil
 	g.ui.roomPanel.SetRoomDefs(nil)
 	// Make sure rooms ain't highlighted
 	for _, room := range s.nextStory.rooms {
@@ -313,8 +403,13 @@ func (s *GameStateBuild) End(g *Game) {
 	g.ToggleEnableUI(false)
 }
 func (s *GameStateBuild) Update(g *Game) GameState {
+	// Compare stash items against whichever dude is selected.
+	if details := g.ui.equipmentPanel.details; details.compare != g.selectedDude || len(details.roster) != len(g.dudes) {
+		g.ui.equipmentPanel.details.SetComparison(g.selectedDude, g.dudes)
+	}
+
 	// Check if we can fill
-	if g.gold < s.DudeCost(len(g.dudes)) {
+	if s.CheapestCandidate(g) < 0 {
 		g.ui.dudePanel.fillButton.hidden = true
 	}
 
@@ -323,16 +418,21 @@ func (s *GameStateBuild) Update(g *Game) GameState {
 			// Always dude-maxxin
 			s.FillDudes(g)
 
-			// Auto-equip the gear
-			if len(g.equipment) > 0 && g.ui.equipmentPanel.autoEquipButton.onClick != nil {
-				g.ui.equipmentPanel.autoEquipButton.onClick()
-			}
-			// Auto-sell the remaining gear
-			if len(g.equipment) > 0 {
-				for _, e := range g.equipment {
-					s.SellEquipment(g, e)
+			// Spend any skill points and promote whoever is ready
+			for _, d := range g.dudes {
+				d.AutoAllocateSkills()
+				if d.CanPromote() && g.gold >= d.PromotionCost() {
+					s.PromoteDude(g, d)
 				}
 			}
+
+			// Auto-equip the gear and sell what's left, by the loot rules
+			if swaps := s.PlanAutoEquip(g); len(swaps) > 0 {
+				s.ApplyAutoEquip(g, swaps)
+			}
+			if slices.ContainsFunc(g.equipment, GetProfile().Loot.Sellable) {
+				s.SellAllEquipment(g)
+			}
 		}
 
 		j := 0
@@ -365,6 +465,10 @@ func (s *GameStateBuild) Update(g *Game) GameState {
 	// Some cancel garbo.
 	if inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
 		g.ui.roomInfoPanel.hidden = true
+		g.ui.tavernPanel.hidden = true
+		g.ui.shopPanel.hidden = true
+		g.ui.forgePanel.hidden = true
+		g.ui.lootRulesPanel.hidden = true
 	}
 
 	s.wobbler += 0.05
@@ -483,7 +587,7 @@ func (s *GameStateBuild) TryPlaceRoom(g *Game) PlaceResult {
 		} else {
 			// If it's not stairs or empty, allow the player to sell it back at full value.
 			if s.focusedRoom.kind != Stairs && s.focusedRoom.kind != Empty {
-				g.gold += GetRoomCost(s.focusedRoom.kind, s.focusedRoom.size, s.nextStory.level)
+				g.Spend(LedgerRooms, -GetRoomCost(s.focusedRoom.kind, s.focusedRoom.size, s.nextStory.level))
 				s.availableRooms = append(s.availableRooms, GetRoomDef(s.focusedRoom.kind, s.focusedRoom.size, s.focusedRoom.required))
 				s.availableRooms = SortRooms(s.availableRooms)
 				// Reselect after sort
@@ -509,7 +613,7 @@ func (s *GameStateBuild) TryPlaceRoom(g *Game) PlaceResult {
 						return PlaceResultFail
 					} else {
 						// it worked!11!
-						g.gold -= GetRoomCost(s.placingRoom.kind, s.placingRoom.size, s.nextStory.level)
+						g.Spend(LedgerRooms, GetRoomCost(s.placingRoom.kind, s.placingRoom.size, s.nextStory.level))
 						g.UpdateInfo()
 						g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("%s %s placed!", s.placingRoom.size.String(), s.placingRoom.kind.String()))
 
@@ -573,7 +677,8 @@ func (s *GameStateBuild) RerollOptionalRooms(g *Game) {
 }
 
 func (s *GameStateBuild) RerollCost() int {
-	return 25 + 75*(s.nextStory.level+1)
+	cost := 25 + 75*(s.nextStory.level+1)
+	return int(float64(cost) * (1 - profile.RerollDiscount()))
 }
 
 func (s *GameStateBuild) RerollRooms(g *Game) {
@@ -582,7 +687,7 @@ func (s *GameStateBuild) RerollRooms(g *Game) {
 		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to reroll rooms! (%d)", cost))
 		return
 	}
-	g.gold -= cost
+	g.Spend(LedgerRerolls, cost)
 	s.RerollOptionalRooms(g)
 	g.UpdateInfo()
 }
@@ -602,81 +707,164 @@ func (s *GameStateBuild) DudeCost(dudeCount int) int {
 	return int(cost)
 }
 
-func (s *GameStateBuild) BuyDude(g *Game) bool {
-	// COST?
-	cost := s.DudeCost(len(g.dudes))
-	if g.gold < cost {
-		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to purchase a dude! (%d)", cost))
+// CandidateCost returns what hiring the candidate costs with the current number of dudes.
+func (s *GameStateBuild) CandidateCost(g *Game, c *Candidate) int {
+	return c.Cost(s.DudeCost(len(g.dudes)))
+}
+
+// TavernRefreshCost returns the cost of a fresh set of tavern candidates.
+func (s *GameStateBuild) TavernRefreshCost() int {
+	return 25 + 25*(s.nextStory.level+1)
+}
+
+// HireCandidate hires the tavern candidate at the given index.
+func (s *GameStateBuild) HireCandidate(g *Game, which int) bool {
+	if which < 0 || which >= len(s.candidates) {
 		return false
 	}
-	g.gold -= cost
-
-	// Average level of all dudes.
-	level := 0
-	for _, d := range g.dudes {
-		level += d.stats.level
+	c := s.candidates[which]
+	cost := s.CandidateCost(g, c)
+	if g.gold < cost {
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to hire %s! (%d)", c.dude.Name(), cost))
+		return false
 	}
-	level /= len(g.dudes)
+	g.Spend(LedgerHires, cost)
+	s.candidates = append(s.candidates[:which], s.candidates[which+1:]...)
 
-	// Random profession ??
-	profession := WeightedRandomProfessionKind(g.dudes)
-	dude := NewDude(profession, level)
+	dude := c.dude
 	if g.simMode {
 		dude.invincible = true
 	}
 	g.dudes = append(g.dudes, dude)
-	g.ui.dudePanel.buyButton.text.SetText(fmt.Sprintf("Hire Dude\n%dgp", s.DudeCost(len(g.dudes))))
+	s.SyncTavern(g)
 	g.UpdateInfo()
 
+	profession := dude.Profession()
 	AddMessage(
 		MessageNeutral,
-		fmt.Sprintf("Hired %s (Level %d %s) for %d gold.", dude.Name(), level, profession.String(), cost),
+		fmt.Sprintf("Hired %s (Level %d %s, %s) for %d gold.", dude.Name(), dude.Level(), profession.String(), TraitsString(dude.Traits()), cost),
 	)
 	return true
 }
 
-func (s *GameStateBuild) EquipmentCost() int {
-	baseCost := 50.0   // Cost on floor 0
-	costAt5 := 500.0   // Cost on floor 5
-	costAt10 := 1000.0 // Cost on floor 10
-	floor5 := 5        // Floor level 5
-	floor10 := 10      // Floor level 10
-	currentStory := s.nextStory.level
-	// Calculate the exponent factor between floor 0 and floor 5
-	exponent1 := math.Log(costAt5/baseCost) / float64(floor5)
+// RefreshTavern pays to replace the tavern candidates with new ones.
+func (s *GameStateBuild) RefreshTavern(g *Game) {
+	cost := s.TavernRefreshCost()
+	if g.gold < cost {
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to refresh the tavern! (%d)", cost))
+		return
+	}
+	g.Spend(LedgerHires, cost)
+	s.candidates = RollCandidates(g.dudes)
+	s.SyncTavern(g)
+	g.UpdateInfo()
+}
 
-	// Calculate the exponent factor between floor 5 and floor 10
-	exponent2 := math.Log(costAt10/costAt5) / float64(floor10-floor5)
+// CheapestCandidate returns the index of the cheapest candidate that can be afforded, or -1 if none can.
+func (s *GameStateBuild) CheapestCandidate(g *Game) int {
+	cheapest := -1
+	for i, c := range s.candidates {
+		cost := s.CandidateCost(g, c)
+		if cost > g.gold {
+			continue
+		}
+		if cheapest < 0 || cost < s.CandidateCost(g, s.candidates[cheapest]) {
+			cheapest = i
+		}
+	}
+	return cheapest
+}
 
-	var cost float64
+// ChangeTaxRate raises or lowers the tax rate taken from the dudes' gold when they return.
+func (s *GameStateBuild) ChangeTaxRate(g *Game, delta float64) {
+	g.SetTaxRate(g.taxRate + delta)
+	percent := int(math.Round(g.taxRate * 100))
+	mood := TaxMood(g.taxRate)
+	switch {
+	case mood > 0:
+		g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("taxes at %d%%, yer dudes feel generous (+%d confidence)", percent, mood))
+	case mood < 0:
+		g.ui.feedback.Msg(FeedbackWarning, fmt.Sprintf("taxes at %d%%, yer dudes grumble (%d confidence)", percent, mood))
+	default:
+		g.ui.feedback.Msg(FeedbackGeneric, fmt.Sprintf("taxes at %d%%", percent))
+	}
+}
 
-	if s.nextStory.level <= floor5 {
-		// Calculate the cost for floors 0 to 5
-		cost = baseCost * math.Exp(exponent1*float64(currentStory))
-	} else {
-		// Calculate the cost for floors 5 to 10
-		cost = costAt5 * math.Exp(exponent2*float64(currentStory-floor5))
+// SyncTavern updates the tavern panel and button with the current candidates and costs.
+// CycleParty moves the selected dude into the next party.
+func (s *GameStateBuild) CycleParty(g *Game) {
+	if g.selectedDude == nil {
+		g.ui.feedback.Msg(FeedbackBad, "select a dude to move between parties!")
+		return
 	}
+	g.selectedDude.CycleParty()
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("%s joined %s", g.selectedDude.Name(), g.parties[g.selectedDude.Party()].Name()))
+	g.UpdateInfo()
+}
 
-	return int(cost)
+func (s *GameStateBuild) SyncTavern(g *Game) {
+	g.ui.tavernPanel.SetCandidates(s.candidates, s.DudeCost(len(g.dudes)))
+	g.ui.tavernPanel.refreshButton.text.SetText(fmt.Sprintf("Refresh\n%dgp", s.TavernRefreshCost()))
+	g.ui.dudePanel.buyButton.text.SetText(fmt.Sprintf("Tavern\n%d for hire", len(s.candidates)))
 }
 
-func (s *GameStateBuild) BuyEquipment(g *Game) {
-	// COST?
-	cost := s.EquipmentCost()
+// ShopRefreshCost returns the cost of a fresh stock at the shop.
+func (s *GameStateBuild) ShopRefreshCost() int {
+	return 50 + 50*(s.nextStory.level+1)
+}
+
+// BuyShopItem buys the shop item at the given index into the stash.
+func (s *GameStateBuild) BuyShopItem(g *Game, which int) bool {
+	if which < 0 || which >= len(g.shop) {
+		return false
+	}
+	si := g.shop[which]
+	cost := si.Price()
 	if g.gold < cost {
-		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to purchase a equipment! (%d)", cost))
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to buy %s! (%d)", si.equipment.Name(), cost))
+		return false
+	}
+	g.Spend(LedgerLoot, cost)
+	g.shop = append(g.shop[:which], g.shop[which+1:]...)
+	g.equipment = append(g.equipment, si.equipment)
+	AddMessage(MessageLoot, fmt.Sprintf("Bought %s for %d gold.", si.equipment.FullName(), cost))
+	s.SyncShop(g)
+	s.SyncEquipment(g)
+	return true
+}
+
+// ReserveShopItem reserves the shop item at the given index so it stays through restocks, or releases it.
+func (s *GameStateBuild) ReserveShopItem(g *Game, which int) {
+	if which < 0 || which >= len(g.shop) {
 		return
 	}
-	g.gold -= cost
+	if ToggleReserve(g.shop, which) {
+		g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("reserved %s", g.shop[which].equipment.Name()))
+	} else {
+		g.ui.feedback.Msg(FeedbackGeneric, fmt.Sprintf("released %s", g.shop[which].equipment.Name()))
+	}
+	s.SyncShop(g)
+}
 
-	level := len(g.tower.Stories)
-	e := GetRandomEquipment(level)
-	g.equipment = append(g.equipment, e)
-	g.ui.equipmentPanel.SetEquipment(g.equipment)
+// RefreshShop pays to restock the shop, keeping the reserved item.
+func (s *GameStateBuild) RefreshShop(g *Game) {
+	cost := s.ShopRefreshCost()
+	if g.gold < cost {
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to refresh the shop! (%d)", cost))
+		return
+	}
+	g.Spend(LedgerLoot, cost)
+	g.shop = RestockShop(g.shop, s.nextStory.level)
+	s.SyncShop(g)
 	g.UpdateInfo()
 }
 
+// SyncShop updates the shop panel and button with the current stock and costs.
+func (s *GameStateBuild) SyncShop(g *Game) {
+	g.ui.shopPanel.SetStock(g.shop, s.ShopRefreshCost())
+	g.ui.equipmentPanel.buyButton.text.SetText(fmt.Sprintf("Shop\n%d for sale", len(g.shop)))
+}
+
 func (s *GameStateBuild) SellEquipment(g *Game, e *Equipment) {
 	if e == nil {
 		return
@@ -693,27 +881,245 @@ func (s *GameStateBuild) SellEquipment(g *Game, e *Equipment) {
 		return
 	}
 	value := int(e.GoldValue())
-	g.gold += value
+	g.Earn(LedgerSales, value)
 	AddMessage(MessageLoot, fmt.Sprintf("Sold %s for %d gold.", e.Name(), value))
 	// Trigger on sell event
 	e.Activate(EventSell{equipment: e, dudes: g.GetAliveDudes()})
 	g.UpdateInfo()
 }
 
+// SelectedEquipment returns the stashed equipment selected in the loot panel, if any.
+func (s *GameStateBuild) SelectedEquipment(g *Game) *Equipment {
+	if s.selectedEquipment < 0 || s.selectedEquipment >= len(g.equipment) || !g.ui.equipmentPanel.showDetails {
+		return nil
+	}
+	return g.equipment[s.selectedEquipment]
+}
+
+// RemoveEquipment takes the equipment out of the stash, returns false if it wasn't there.
+func (s *GameStateBuild) RemoveEquipment(g *Game, e *Equipment) bool {
+	for i, eq := range g.equipment {
+		if eq == e {
+			g.equipment = append(g.equipment[:i], g.equipment[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ForgeEquipment forges the items of the forge group at the given index into one of the next quality.
+func (s *GameStateBuild) ForgeEquipment(g *Game, index int) {
+	if index < 0 || index >= len(s.forgeGroups) {
+		return
+	}
+	fg := s.forgeGroups[index]
+	if g.materials < fg.MaterialCost() {
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need %d materials to forge that! salvage some loot", fg.MaterialCost()))
+		return
+	}
+	forged := fg.Forge()
+	if forged == nil {
+		return
+	}
+	g.materials -= fg.MaterialCost()
+	for _, e := range fg.Inputs() {
+		s.RemoveEquipment(g, e)
+	}
+	g.equipment = append(g.equipment, forged)
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("forged %s!", forged.Name()))
+	AddMessage(MessageLoot, fmt.Sprintf("Forged %d %s %s into %s.", ForgeCount, fg.quality, fg.name, forged.FullName()))
+	s.SyncEquipment(g)
+}
+
+// SalvageEquipment breaks the selected stash item down into materials.
+func (s *GameStateBuild) SalvageEquipment(g *Game) {
+	e := s.SelectedEquipment(g)
+	if e == nil || !s.RemoveEquipment(g, e) {
+		return
+	}
+	g.materials += e.SalvageValue()
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("salvaged %s for %d materials", e.Name(), e.SalvageValue()))
+	s.SyncEquipment(g)
+}
+
+// ReforgeEquipment rerolls the perk of the selected stash item for gold.
+func (s *GameStateBuild) ReforgeEquipment(g *Game) {
+	e := s.SelectedEquipment(g)
+	if e == nil || e.IsConsumable() || e.IsUnique() {
+		return
+	}
+	cost := e.ReforgeCost()
+	if g.gold < cost {
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need %d gold to reforge %s!", cost, e.Name()))
+		return
+	}
+	g.Spend(LedgerForging, cost)
+	e.Reforge()
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("reforged %s", e.FullName()))
+	g.ui.equipmentPanel.details.SetEquipment(e)
+	s.SyncForge(g)
+	g.UpdateInfo()
+}
+
+// AutoEquip previews the swaps auto-equip would make on the first click, then makes them on the next.
+func (s *GameStateBuild) AutoEquip(g *Game) {
+	if len(g.equipment) == 0 {
+		return
+	}
+	swaps := s.PlanAutoEquip(g)
+	if len(swaps) == 0 {
+		g.ui.feedback.Msg(FeedbackGeneric, "none of yer dudes want any of this")
+		s.SyncAutoEquip(g, false)
+		return
+	}
+
+	if !s.autoEquipPending {
+		for _, swap := range swaps {
+			AddMessage(MessageNeutral, swap.String())
+		}
+		g.ui.feedback.Msg(FeedbackGeneric, fmt.Sprintf("%d swaps planned, check the log and click again to apply", len(swaps)))
+		s.SyncAutoEquip(g, true)
+		return
+	}
+
+	s.ApplyAutoEquip(g, swaps)
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("made %d swaps", len(swaps)))
+}
+
+// PlanAutoEquip plans the auto-equip swaps for the stash under the loot rules.
+func (s *GameStateBuild) PlanAutoEquip(g *Game) []AutoEquipSwap {
+	policy := GetProfile().Loot
+	return PlanAutoEquip(policy.DudeOrder(g.dudes), SortEquipment(SortPropertyLevel, g.equipment), policy)
+}
+
+// ApplyAutoEquip makes the planned swaps, stashing whatever comes off.
+func (s *GameStateBuild) ApplyAutoEquip(g *Game, swaps []AutoEquipSwap) {
+	for _, swap := range swaps {
+		s.RemoveEquipment(g, swap.equipment)
+		g.equipment = append(g.equipment, swap.dude.Equip(swap.equipment)...)
+	}
+	s.SyncEquipment(g)
+}
+
+// SyncAutoEquip sets whether an auto-equip preview is waiting to be confirmed.
+func (s *GameStateBuild) SyncAutoEquip(g *Game, pending bool) {
+	s.autoEquipPending = pending
+	if pending {
+		g.ui.equipmentPanel.autoEquipButton.text.SetText("Apply\nSwaps")
+	} else {
+		g.ui.equipmentPanel.autoEquipButton.text.SetText("Auto\nEquip")
+	}
+}
+
+// SyncEquipment refreshes the stash and forge after items come or go.
+func (s *GameStateBuild) SyncEquipment(g *Game) {
+	s.SyncAutoEquip(g, false)
+	g.equipment = SortEquipment(g.ui.equipmentPanel.sortMethod, g.equipment)
+	g.ui.equipmentPanel.SetEquipment(g.equipment)
+	if s.selectedEquipment >= len(g.equipment) {
+		s.selectedEquipment = len(g.equipment) - 1
+	}
+	if s.selectedEquipment >= 0 {
+		g.ui.equipmentPanel.onItemClick(s.selectedEquipment)
+	} else {
+		g.ui.equipmentPanel.details.SetEquipment(nil)
+		g.ui.equipmentPanel.showDetails = false
+	}
+	s.SyncForge(g)
+	g.UpdateInfo()
+}
+
+// SyncForge refreshes the forge recipes and the selected item's options.
+func (s *GameStateBuild) SyncForge(g *Game) {
+	s.forgeGroups = ForgeGroups(g.equipment)
+	g.ui.forgePanel.SetForge(s.forgeGroups, s.SelectedEquipment(g), g.materials)
+}
+
+// FillDudes hires the cheapest affordable tavern candidates until the gold or candidates run out.
 func (s *GameStateBuild) FillDudes(g *Game) {
 	for {
-		if !s.BuyDude(g) {
+		which := s.CheapestCandidate(g)
+		if which < 0 || !s.HireCandidate(g, which) {
 			break
 		}
 	}
 }
 
+// SellAllEquipment sells the stash, keeping anything the loot rules say to keep.
 func (s *GameStateBuild) SellAllEquipment(g *Game) {
+	policy := GetProfile().Loot
 	equipmentList := make([]*Equipment, len(g.equipment))
 	copy(equipmentList, g.equipment)
 	for _, e := range equipmentList {
-		s.SellEquipment(g, e)
+		if policy.Sellable(e) {
+			s.SellEquipment(g, e)
+		}
 	}
-	g.equipment = make([]*Equipment, 0)
-	g.ui.equipmentPanel.SetEquipment(g.equipment)
+	if kept := len(g.equipment); kept > 0 && !g.autoplay {
+		g.ui.feedback.Msg(FeedbackGeneric, fmt.Sprintf("kept %d items by the loot rules", kept))
+	}
+	s.SyncEquipment(g)
+}
+
+// SyncLootRules shows the loot rules and saves them to the profile.
+func (s *GameStateBuild) SyncLootRules(g *Game) {
+	g.ui.lootRulesPanel.SetPolicy(GetProfile().Loot)
+	SaveProfile()
+}
+
+// PromoteDude promotes the dude if it meets the requirements and the gold is there.
+func (s *GameStateBuild) PromoteDude(g *Game, d *Dude) {
+	if !d.CanPromote() {
+		base := d.Profession()
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("%s must reach level %d and %s", d.Name(), PromotionLevel, base.PromotionRequirement()))
+		return
+	}
+	cost := d.PromotionCost()
+	if g.gold < cost {
+		g.ui.feedback.Msg(FeedbackBad, "ur broke lol")
+		return
+	}
+	g.Spend(LedgerServices, cost)
+	d.Promote()
+	promoted := d.Profession()
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("%s is now a %s!", d.Name(), promoted.String()))
+	g.UpdateInfo()
+	g.ui.dudeInfoPanel.SyncDude()
+}
+
+// ReviveDude revives the most recently fallen dude for gold.
+func (s *GameStateBuild) ReviveDude(g *Game) bool {
+	if len(g.fallen) == 0 {
+		return false
+	}
+	d := g.fallen[len(g.fallen)-1]
+	cost := d.ReviveCost()
+	if g.gold < cost {
+		g.ui.feedback.Msg(FeedbackBad, fmt.Sprintf("need more gold to revive %s! (%d)", d.Name(), cost))
+		return false
+	}
+	g.Spend(LedgerServices, cost)
+	g.fallen = g.fallen[:len(g.fallen)-1]
+
+	// Their body is no longer in the tower.
+	d.story = nil
+	d.room = nil
+	d.Revive(1)
+	g.dudes = append(g.dudes, d)
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("%s has returned from the dead!", d.Name()))
+	s.SyncTavern(g)
+	s.SyncReviveButton(g)
+	g.UpdateInfo()
+	return true
+}
+
+// SyncReviveButton shows the revive button for the most recently fallen dude, if any.
+func (s *GameStateBuild) SyncReviveButton(g *Game) {
+	if len(g.fallen) == 0 {
+		g.ui.dudePanel.reviveButton.hidden = true
+		return
+	}
+	d := g.fallen[len(g.fallen)-1]
+	g.ui.dudePanel.reviveButton.text.SetText(fmt.Sprintf("Revive %s\n%dgp", d.Name(), d.ReviveCost()))
+	g.ui.dudePanel.reviveButton.hidden = false
 }
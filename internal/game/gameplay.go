@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"image/color"
 	"math"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -16,8 +17,34 @@ type GameStatePlay struct {
 	titleTimer     int
 	wobbler        float64
 	updateTicker   int
+	ticks          int // Tower updates since the run started.
 	returningDudes []*Dude
 	boss           *Enemy
+	report         RunReport
+}
+
+// RunReport sums up what came back from a run.
+type RunReport struct {
+	returned      int
+	fallen        int
+	gold          int
+	kept          int
+	items         int
+	recoveredGold int
+	taxedGold     int
+	unrecovered   []string
+}
+
+// Print adds the report to the message log.
+func (r *RunReport) Print() {
+	AddMessage(MessageNeutral, fmt.Sprintf("Run report: %d dudes returned, %d fell.", r.returned, r.fallen))
+	AddMessage(MessageLoot, fmt.Sprintf("Taxed %d gold and collected %d items, dudes kept %d gold.", r.gold, r.items, r.kept))
+	if r.recoveredGold > 0 || r.taxedGold > 0 {
+		AddMessage(MessageLoot, fmt.Sprintf("Recovered %d gold from the fallen after a %d gold tax.", r.recoveredGold, r.taxedGold))
+	}
+	if len(r.unrecovered) > 0 {
+		AddMessage(MessageBad, fmt.Sprintf("Lost with the fallen: %s", strings.Join(r.unrecovered, ", ")))
+	}
 }
 
 func (s *GameStatePlay) Begin(g *Game) {
@@ -25,18 +52,42 @@ func (s *GameStatePlay) Begin(g *Game) {
 	for _, d := range g.dudes {
 		d.stack.Transparency = 0
 		d.shadow.Transparency = 0
+		d.SetTaxMood(g.taxRate)
+		d.runGold = 0
+		d.ResetMorale()
+		d.ResetDecisions()
 	}
 
-	// Add our dudes to the tower!
-	g.tower.AddDudes(g.dudes...)
+	// Send out the parties that depart at once.
+	for _, p := range g.parties {
+		p.Reset()
+	}
+	s.DepartParties(g)
 
 	g.camera.SetRotation(math.Pi / 8)
 	g.camera.SetStory(0)
 }
 func (s *GameStatePlay) End(g *Game) {
+	// Pick over whatever the fallen left behind before the rooms are cleared.
+	s.RecoverCorpses(g)
+
+	// Each story cleared earns legacy.
+	if !g.simMode {
+		s.AwardLegacy(g)
+	}
+
 	// Reset tower
 	g.tower.Reset()
 
+	// Keep the fallen around so they can be revived.
+	for _, d := range g.dudes {
+		if d.IsDead() {
+			g.fallen = append(g.fallen, d)
+			s.report.fallen++
+		}
+	}
+	s.report.returned = len(s.returningDudes)
+
 	// Replace our current dudes!
 	g.dudes = nil
 	g.dudes = append(g.dudes, s.returningDudes...)
@@ -66,6 +117,8 @@ func (s *GameStatePlay) End(g *Game) {
 	s.CollectGold(g)
 	// Collect inventory
 	s.CollectInventory(g)
+
+	s.report.Print()
 }
 func (s *GameStatePlay) Update(g *Game) GameState {
 	if g.autoplay {
@@ -103,6 +156,9 @@ func (s *GameStatePlay) Update(g *Game) GameState {
 	if !g.paused {
 		s.updateTicker++
 		if s.updateTicker > g.speed {
+			s.ticks++
+			s.DepartParties(g)
+
 			var req ActivityRequests
 			g.tower.Update(&req, g)
 			for _, u := range req {
@@ -114,7 +170,7 @@ func (s *GameStatePlay) Update(g *Game) GameState {
 					// If the game has no more alive dudes, we lose
 					if len(aliveDudes) == 0 {
 						return &GameStateLose{}
-					} else if !g.tower.HasAliveDudes() {
+					} else if !g.tower.HasAliveDudes() && !g.PartiesPending() {
 						// However, if the game has alive and the tower does not, continue
 						g.tower.ClearBodies()
 						return &GameStateBuild{}
@@ -123,20 +179,29 @@ func (s *GameStatePlay) Update(g *Game) GameState {
 					if !u.dude.IsDead() {
 						s.returningDudes = append(s.returningDudes, u.dude)
 						g.tower.RemoveDude(u.dude)
-						if !g.tower.HasAliveDudes() {
+						if !g.tower.HasAliveDudes() && !g.PartiesPending() {
 							return &GameStateWin{}
 						}
 					}
 				case TowerLeaveActivity:
+					g.parties[u.dude.party].Clear(g.tower.OpenStories())
 					if !u.dude.IsDead() {
 						s.returningDudes = append(s.returningDudes, u.dude)
 						g.tower.RemoveDude(u.dude)
-						if !g.tower.HasAliveDudes() {
+						if !g.tower.HasAliveDudes() && !g.PartiesPending() {
 							// No more alive dudes! Switch game state, yo.
 							g.tower.ClearBodies()
 							return &GameStateBuild{}
 						}
 					}
+				case TowerFleeActivity:
+					if !u.dude.IsDead() {
+						s.returningDudes = append(s.returningDudes, u.dude)
+						if !g.tower.HasAliveDudes() && !g.PartiesPending() {
+							g.tower.ClearBodies()
+							return &GameStateBuild{}
+						}
+					}
 				case RoomStartBossActivity:
 					g.ui.feedback.Msg(FeedbackBad, "a boss -- can ur dudes make it??")
 					g.ui.bossPanel.hidden = false
@@ -144,6 +209,13 @@ func (s *GameStatePlay) Update(g *Game) GameState {
 					g.ui.bossPanel.text.SetText(u.room.boss.Name())
 					s.boss = u.room.boss
 				case RoomEndBossActivity:
+					// Every dude that fought reports in, only pay out once.
+					if s.boss != nil {
+						bounty := BossBounty(u.room.story.level)
+						g.Earn(LedgerBounties, bounty)
+						AddMessage(MessageLoot, fmt.Sprintf("The treasury collected a %d gold bounty for the %s.", bounty, s.boss.Name()))
+						g.UpdateInfo()
+					}
 					g.ui.bossPanel.hidden = true
 					s.boss = nil
 				}
@@ -213,20 +285,58 @@ func (s *GameStatePlay) Draw(g *Game, screen *ebiten.Image) {
 	}
 }
 
-// For all dudes, remove their gold and add it to the player's gold.
+// DepartParties sends the parties that are ready into the tower, noting how far the ones inside have made it.
+func (s *GameStatePlay) DepartParties(g *Game) {
+	for _, d := range g.tower.dudes {
+		if d.story != nil {
+			g.parties[d.party].Clear(d.story.level)
+		}
+	}
+	departed := false
+	for i, p := range g.parties {
+		if !p.departed && p.Ready(s.ticks, g.parties) {
+			departed = s.DepartParty(g, i) || departed
+		}
+	}
+	// Nobody left in the tower to wait on, so send the next party instead of waiting forever.
+	if !departed && !g.tower.HasAliveDudes() {
+		for i, p := range g.parties {
+			if !p.departed && s.DepartParty(g, i) {
+				break
+			}
+		}
+	}
+}
+
+// DepartParty sends the party's living dudes into the tower, returns false if it had none.
+func (s *GameStatePlay) DepartParty(g *Game, index int) bool {
+	g.parties[index].departed = true
+	dudes := g.PartyDudes(index)
+	if len(dudes) == 0 {
+		return false
+	}
+	g.tower.AddDudes(dudes...)
+	AddMessage(MessageNeutral, fmt.Sprintf("%s set out with %d dudes", g.parties[index].Name(), len(dudes)))
+	return true
+}
+
+// For all dudes, tax their gold and add it to the player's gold. The rest stays with the dudes to spend.
 func (s *GameStatePlay) CollectGold(g *Game) {
 	gold := 0
+	kept := 0
 	for _, dude := range g.dudes {
-		gold += dude.gold
-		dude.gold = 0
+		gold += dude.Tax(g.taxRate)
+		kept += dude.gold
 	}
+	s.report.kept = kept
 	if gold == 0 {
 		return
 	}
-	g.gold += gold
+	g.Earn(LedgerTaxes, gold)
+	s.report.gold += gold
 
-	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("%d gold snarfed from yer dudes", int(gold)))
-	AddMessage(MessageLoot, fmt.Sprintf("Collected %d gold from dudes.", int(gold)))
+	g.ui.feedback.Msg(FeedbackGood, fmt.Sprintf("%d gold taxed from yer dudes", int(gold)))
+	AddMessage(MessageLoot, fmt.Sprintf("Taxed %d gold from dudes at %d%%.", int(gold), int(math.Round(g.taxRate*100))))
 	g.UpdateInfo()
 }
 
@@ -241,7 +351,52 @@ func (s *GameStatePlay) CollectInventory(g *Game) {
 	if count == 0 {
 		return
 	}
+	s.report.items += count
 	AddMessage(MessageLoot, fmt.Sprintf("Collected %d items from dudes.", count))
 	e := SortEquipment(g.ui.equipmentPanel.sortMethod, g.equipment)
 	g.ui.equipmentPanel.SetEquipment(e)
 }
+
+// RecoverCorpses recovers the gold from unlooted corpses at a tax, their equipment is lost.
+func (s *GameStatePlay) RecoverCorpses(g *Game) {
+	for _, st := range g.tower.Stories {
+		for _, room := range st.rooms {
+			if room == nil {
+				continue
+			}
+			for _, c := range room.corpses {
+				if c.looted {
+					continue
+				}
+				for _, eq := range c.Strip() {
+					s.report.unrecovered = append(s.report.unrecovered, fmt.Sprintf("%s (%s)", eq.Name(), c.dude.name))
+				}
+				tax := int(float64(c.dude.gold) * CorpseTax)
+				s.report.recoveredGold += c.dude.gold - tax
+				s.report.taxedGold += tax
+				c.dude.gold = 0
+			}
+		}
+	}
+	if s.report.recoveredGold > 0 {
+		g.Earn(LedgerRecovered, s.report.recoveredGold)
+		g.UpdateInfo()
+	}
+}
+
+// AwardLegacy awards legacy for each story the dudes made it through for the first time.
+func (s *GameStatePlay) AwardLegacy(g *Game) {
+	paid := false
+	for _, st := range g.tower.Stories {
+		if !st.cleared || st.legacyPaid {
+			continue
+		}
+		st.legacyPaid = true
+		paid = true
+		amount := profile.AddLegacy(st.level)
+		AddMessage(MessageGood, fmt.Sprintf("Earned %d legacy for clearing story %d.", amount, st.level+1))
+	}
+	if paid {
+		SaveProfile()
+	}
+}
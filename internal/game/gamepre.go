@@ -1,6 +1,9 @@
 package game
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
 	"github.com/kettek/ebijam24/assets"
@@ -16,6 +19,9 @@ type GameStatePre struct {
 	infinite ButtonPanel
 	sim      ButtonPanel
 	info     *UIText
+	legacy   *UIText
+	heroes   *UIText
+	unlocks  []ButtonPanel
 
 	//
 	gameLength int
@@ -78,17 +84,74 @@ func (s *GameStatePre) Begin(g *Game) {
 
 	s.info = NewUIText("beep boop", assets.BodyFont, assets.ColorStory)
 
+	// Meta-progression
+	s.legacy = NewUIText("", assets.BodyFont, assets.ColorHeading)
+	s.heroes = NewUIText("", assets.BodyFont, assets.ColorDudeTitle)
+	s.unlocks = nil
+	for u := UnlockKind(0); u < UnlockKindEnd; u++ {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.onClick = func() {
+			if err := profile.Buy(u); err != nil {
+				s.info.SetText(err.Error())
+				return
+			}
+			SaveProfile()
+			s.SyncProfile()
+			s.info.SetText(fmt.Sprintf("unlocked %s rank %d!", u, profile.Rank(u)))
+		}
+		btn.onHover = func() {
+			rank := profile.Rank(u)
+			if rank >= u.MaxRank() {
+				s.info.SetText(fmt.Sprintf("%s is fully unlocked.", u))
+				return
+			}
+			s.info.SetText(fmt.Sprintf("%s (%d legacy)", u.Description(), u.Cost(rank+1)))
+		}
+		s.unlocks = append(s.unlocks, btn)
+	}
+	s.SyncProfile()
+
 	// Init inventory
 	g.equipment = make([]*Equipment, 0)
+	g.materials = 0
+	g.shop = nil
 	g.ui.equipmentPanel.SetEquipment(g.equipment)
 
+	// Init treasury
+	g.treasury = NewTreasury()
+	g.SetTaxRate(TaxRateDefault)
+	g.ui.treasuryPanel.SetLedger(g.treasury.Summary(3))
+
 	// Init dudes
+	g.parties = NewParties()
 	g.dudes = make([]*Dude, 0)
+	g.fallen = nil
 	g.ui.dudeInfoPanel.SetDude(nil)
 	g.ui.dudePanel.SetDudes(g.dudes)
 	g.ui.dudeInfoPanel.equipmentDetails.SetEquipment(nil)
 }
 
+// SyncProfile refreshes the legacy, unlock and hall of heroes texts.
+func (s *GameStatePre) SyncProfile() {
+	s.legacy.SetText(fmt.Sprintf("legacy: %d", profile.Legacy))
+	for i := range s.unlocks {
+		u := UnlockKind(i)
+		s.unlocks[i].text.SetText(fmt.Sprintf("%s %d/%d", u, profile.Rank(u), u.MaxRank()))
+	}
+
+	if len(profile.Heroes) == 0 {
+		s.heroes.SetText("")
+		return
+	}
+	// Show the most recent heroes.
+	var names []string
+	for i := len(profile.Heroes) - 1; i >= 0 && len(names) < 3; i-- {
+		h := profile.Heroes[i]
+		names = append(names, fmt.Sprintf("%s (lvl %d %s)", h.Name, h.Level, h.Profession))
+	}
+	s.heroes.SetText(fmt.Sprintf("hall of heroes (%d): %s", len(profile.Heroes), strings.Join(names, ", ")))
+}
+
 func (s *GameStatePre) End(g *Game) {
 	g.ui.Reveal()
 }
@@ -139,10 +202,35 @@ func (s *GameStatePre) Update(g *Game) GameState {
 	y += 32 + 4*g.uiOptions.Scale
 	s.sim.SetPosition(w/2-s.sim.Width()/2, y)
 
+	// Put meta-progression below sim mode
+	y += s.sim.Height() + 8*g.uiOptions.Scale
+	s.legacy.Layout(nil, &g.uiOptions)
+	s.legacy.SetPosition(w/2-s.legacy.Width()/2, y)
+	y += s.legacy.Height() + 4*g.uiOptions.Scale
+
+	unlocksWidth := 0.0
+	for i := range s.unlocks {
+		s.unlocks[i].Layout(nil, &g.uiOptions)
+		unlocksWidth += s.unlocks[i].Width()
+	}
+	unlocksX := (w - unlocksWidth) / 2
+	for i := range s.unlocks {
+		s.unlocks[i].SetPosition(unlocksX, y)
+		unlocksX += s.unlocks[i].Width()
+	}
+	if len(s.unlocks) > 0 {
+		y += s.unlocks[0].Height() + 4*g.uiOptions.Scale
+	}
+
+	s.heroes.Layout(nil, &g.uiOptions)
+	s.heroes.SetPosition(w/2-s.heroes.Width()/2, y)
+
 	click := ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft)
 	if len(g.releasedTouchIDs) > 0 && inpututil.IsTouchJustReleased(g.releasedTouchIDs[0]) {
 		click = true
 	}
+	// Unlocks cost legacy, so only buy on a fresh click.
+	justClicked := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) || (len(g.releasedTouchIDs) > 0 && inpututil.IsTouchJustReleased(g.releasedTouchIDs[0]))
 	mx, my := g.CursorPosition()
 	if s.short.Check(mx, my, UICheckHover) {
 		if click {
@@ -164,6 +252,8 @@ func (s *GameStatePre) Update(g *Game) GameState {
 		if click {
 			s.sim.Check(mx, my, UICheckClick)
 		}
+	} else if s.CheckUnlocks(mx, my, justClicked) {
+		// Handled
 	} else {
 		s.info.SetText("")
 	}
@@ -175,6 +265,20 @@ func (s *GameStatePre) Update(g *Game) GameState {
 	}
 	return nil
 }
+
+// CheckUnlocks hovers and clicks the unlock buttons, returns true if one was hovered.
+func (s *GameStatePre) CheckUnlocks(mx, my float64, click bool) bool {
+	for i := range s.unlocks {
+		if s.unlocks[i].Check(mx, my, UICheckHover) {
+			if click {
+				s.unlocks[i].Check(mx, my, UICheckClick)
+			}
+			return true
+		}
+	}
+	return false
+}
+
 func (s *GameStatePre) Draw(g *Game, screen *ebiten.Image) {
 	opts := &render.Options{
 		Screen: screen,
@@ -187,4 +291,9 @@ func (s *GameStatePre) Draw(g *Game, screen *ebiten.Image) {
 	s.long.Draw(opts)
 	s.infinite.Draw(opts)
 	s.sim.Draw(opts)
+	s.legacy.Draw(opts)
+	for i := range s.unlocks {
+		s.unlocks[i].Draw(opts)
+	}
+	s.heroes.Draw(opts)
 }
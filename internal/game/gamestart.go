@@ -1,6 +1,8 @@
 package game
 
 import (
+	"fmt"
+
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/kettek/ebijam24/internal/render"
 )
@@ -18,11 +20,14 @@ func (s *GameStateStart) Begin(g *Game) {
 		s.length = 3
 	}
 
+	// Every run gets a fresh combat log.
+	ResetCombatLog()
+
 	// Give the player a reasonable amount of GOLD
-	g.gold = 750
+	g.gold = profile.StartingGold()
 
 	professions := []ProfessionKind{Knight, Vagabond, Ranger, Cleric}
-	dudeLimit := len(professions) * 2
+	dudeLimit := len(professions)*2 + profile.StartingDudes()
 	for i := 0; i < dudeLimit; i++ {
 		pk := professions[i%len(professions)]
 		dude := NewDude(pk, 1)
@@ -43,6 +48,19 @@ func (s *GameStateStart) Begin(g *Game) {
 		tower.targetStories = 3 + s.length*3
 	}
 
+	// Hand over any heirlooms kept from the last run.
+	if heirlooms := profile.TakeHeirlooms(); len(heirlooms) > 0 {
+		g.equipment = append(g.equipment, heirlooms...)
+		g.ui.equipmentPanel.SetEquipment(g.equipment)
+		for _, e := range heirlooms {
+			AddMessage(MessageLoot, fmt.Sprintf("The heirloom %s awaits a new owner.", e.Name()))
+		}
+		SaveProfile()
+	}
+
+	// Only one unique is found per run, and never one the player already has.
+	ResetUniqueDrops(g.equipment)
+
 	g.tower = tower
 	g.camera.SetMode(render.CameraModeTower)
 	g.ui.hint.Show()
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"fmt"
 	"image/color"
 	"math"
 
@@ -12,27 +13,156 @@ import (
 )
 
 type GameStateWin struct {
-	wobbler float64
+	wobbler   float64
+	heading   *UIText
+	heirlooms []*HeirloomChoice
+}
+
+// HeirloomChoice is an escaped hero's pick of which equipped item to keep as an heirloom.
+type HeirloomChoice struct {
+	dude   *Dude
+	items  []*Equipment // Equipped items in slot order.
+	index  int          // Picked item, or len(items) to keep nothing.
+	button ButtonPanel
+}
+
+// NewHeirloomChoice creates the choice for the dude, starting on their most valuable item.
+func NewHeirloomChoice(d *Dude) *HeirloomChoice {
+	c := &HeirloomChoice{
+		dude:   d,
+		button: MakeButtonPanel(assets.BodyFont, PanelStyleButton),
+	}
+	for slot := EquipmentSlotHead; slot < EquipmentSlotEnd; slot++ {
+		if eq := d.equipped[slot]; eq != nil {
+			if len(c.items) > 0 && eq.GoldValue() > c.items[c.index].GoldValue() {
+				c.index = len(c.items)
+			}
+			c.items = append(c.items, eq)
+		}
+	}
+	c.button.onClick = c.Cycle
+	c.SyncText()
+	return c
+}
+
+// Picked returns the item picked to keep, or nil if keeping nothing.
+func (c *HeirloomChoice) Picked() *Equipment {
+	if c.index >= len(c.items) {
+		return nil
+	}
+	return c.items[c.index]
+}
+
+// Cycle picks the next item, going through keeping nothing before wrapping around.
+func (c *HeirloomChoice) Cycle() {
+	c.index = (c.index + 1) % (len(c.items) + 1)
+	c.SyncText()
+}
+
+// SyncText shows the picked item on the button.
+func (c *HeirloomChoice) SyncText() {
+	if eq := c.Picked(); eq != nil {
+		c.button.text.SetText(fmt.Sprintf("%s keeps %s", c.dude.Name(), eq.Name()))
+	} else {
+		c.button.text.SetText(fmt.Sprintf("%s keeps nothing", c.dude.Name()))
+	}
 }
 
 func (s *GameStateWin) Begin(g *Game) {
 	g.camera.SetMode(render.CameraModeTower)
 	g.audioController.PauseRoomTracks()
 	g.audioController.PlaySfx("win", 0.5, 0.0)
+
+	// Escaped dudes enter the Hall of Heroes, where the player picks the heirloom each leaves behind.
+	s.heading = NewUIText("hall of heroes, click to pick the heirlooms kept for next time:", assets.BodyFont, assets.ColorHeading)
+	if !g.simMode {
+		for _, d := range g.dudes {
+			if d.IsDead() {
+				continue
+			}
+			profile.AddHero(d, len(g.tower.Stories))
+			s.heirlooms = append(s.heirlooms, NewHeirloomChoice(d))
+		}
+		SaveProfile()
+	}
 }
+
+// KeepHeirlooms keeps the picked heirlooms for the next run.
+func (s *GameStateWin) KeepHeirlooms() {
+	kept := false
+	for _, c := range s.heirlooms {
+		if eq := c.Picked(); eq != nil {
+			profile.KeepHeirloom(c.dude, eq)
+			kept = true
+		}
+	}
+	if kept {
+		SaveProfile()
+	}
+}
+
 func (s *GameStateWin) End(g *Game) {
 	g.titleFadeOutTick = 1
 }
 
 func (s *GameStateWin) Update(g *Game) GameState {
 	s.wobbler += 0.05
-	if inpututil.IsKeyJustPressed(ebiten.KeySpace) || (len(g.releasedTouchIDs) > 0 && inpututil.IsTouchJustReleased(g.releasedTouchIDs[0])) {
+	if inpututil.IsKeyJustPressed(ebiten.KeySpace) {
+		s.KeepHeirlooms()
+		return &GameStatePre{}
+	}
+	if len(s.heirlooms) > 0 {
+		s.LayoutHeirlooms(g)
+		justClicked := inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) || (len(g.releasedTouchIDs) > 0 && inpututil.IsTouchJustReleased(g.releasedTouchIDs[0]))
+		mx, my := g.CursorPosition()
+		for _, c := range s.heirlooms {
+			if c.button.Check(mx, my, UICheckHover) && justClicked {
+				c.button.Check(mx, my, UICheckClick)
+			}
+		}
+	} else if len(g.releasedTouchIDs) > 0 && inpututil.IsTouchJustReleased(g.releasedTouchIDs[0]) {
 		return &GameStatePre{}
 	}
 	g.camera.SetRotation(g.camera.Rotation() + 0.005)
 	return nil
 }
 
+// LayoutHeirlooms places the heirloom picks in rows below the win text.
+func (s *GameStateWin) LayoutHeirlooms(g *Game) {
+	w, h := float64(g.uiOptions.Width), float64(g.uiOptions.Height)
+	_, th := text.Measure("Press SPACE to try again!", assets.BodyFont.Face, assets.BodyFont.LineHeight)
+	y := h/2 + 20*4 + th*4*2
+
+	s.heading.Layout(nil, &g.uiOptions)
+	s.heading.SetPosition(w/2-s.heading.Width()/2, y)
+	y += s.heading.Height() + 4*g.uiOptions.Scale
+
+	// Fill rows as wide as the screen allows, centering each.
+	var row []*HeirloomChoice
+	rowWidth := 0.0
+	placeRow := func() {
+		x := w/2 - rowWidth/2
+		for _, c := range row {
+			c.button.SetPosition(x, y)
+			x += c.button.Width()
+		}
+		if len(row) > 0 {
+			y += row[0].button.Height()
+		}
+		row = nil
+		rowWidth = 0
+	}
+	for _, c := range s.heirlooms {
+		c.button.Layout(nil, &g.uiOptions)
+		if len(row) > 0 && rowWidth+c.button.Width() > w-16*g.uiOptions.Scale {
+			placeRow()
+		}
+		row = append(row, c)
+		rowWidth += c.button.Width()
+	}
+	placeRow()
+}
+
 func (s *GameStateWin) Draw(g *Game, screen *ebiten.Image) {
 	s.DrawRainbow(screen, "U   WINNE!1!")
 
@@ -68,6 +198,15 @@ func (s *GameStateWin) Draw(g *Game, screen *ebiten.Image) {
 
 		opts.GeoM.Translate(float64(screen.Bounds().Dx()/2)-w/2, y+h)
 		render.DrawText(opts, "Press SPACE to try again!")
+		y += h * 2
+	}
+
+	if len(s.heirlooms) > 0 {
+		o := &render.Options{Screen: screen}
+		s.heading.Draw(o)
+		for _, c := range s.heirlooms {
+			c.button.Draw(o)
+		}
 	}
 
 }
@@ -0,0 +1,288 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// LootEntry is a weighted entry within a loot table.
+type LootEntry struct {
+	equipment string          // Specific equipment to drop, if empty one of types is used.
+	types     []EquipmentType // Types to pick a random equipment from.
+	weight    int             // Relative chance of this entry being picked.
+	unique    string          // Unique from the catalog to drop instead, if any. Nothing drops if a unique already has this run or the player owns it.
+}
+
+// Name returns the equipment name to create for the entry.
+func (le *LootEntry) Name() string {
+	if le.equipment != "" {
+		return le.equipment
+	}
+	types := le.types
+	if types == nil {
+		types = []EquipmentType{EquipmentTypeWeapon, EquipmentTypeArmor, EquipmentTypeAccessory}
+	}
+	names := GetEquipmentNamesWithTypes(types)
+	if len(names) == 0 {
+		return ""
+	}
+	return *names[rand.Intn(len(names))]
+}
+
+// LootTable describes what an enemy rewards when defeated.
+type LootTable struct {
+	dropChance   float64          // Base chance to roll an entry, luck increases it.
+	maxChance    float64          // Maximum chance to roll an entry.
+	entries      []LootEntry      // Weighted entries, one is picked per drop.
+	guaranteed   []LootEntry      // Entries that always drop.
+	uniques      []LootEntry      // Catalog uniques, each dropping with UniqueBossChance.
+	qualityFloor EquipmentQuality // Lowest quality dropped equipment can be.
+	perkChance   float64          // Base chance for a dropped item to have a perk.
+	goldMin      int              // Minimum gold at level 1.
+	goldMax      int              // Maximum gold at level 1.
+	xp           int              // XP at level 1.
+}
+
+var anyEquipment = []LootEntry{
+	{types: []EquipmentType{EquipmentTypeWeapon}, weight: 1},
+	{types: []EquipmentType{EquipmentTypeArmor}, weight: 1},
+	{types: []EquipmentType{EquipmentTypeAccessory}, weight: 1},
+}
+
+// LootTable returns the loot table for the enemy kind.
+func (e EnemyKind) LootTable() *LootTable {
+	switch e {
+	case EnemyRat:
+		return &LootTable{
+			dropChance: 0.1,
+			maxChance:  0.5,
+			entries: []LootEntry{
+				{types: []EquipmentType{EquipmentTypeAccessory}, weight: 3},
+				{types: []EquipmentType{EquipmentTypeArmor}, weight: 1},
+				{types: []EquipmentType{EquipmentTypeWeapon}, weight: 1},
+			},
+			perkChance: 0.05,
+			goldMin:    15,
+			goldMax:    45,
+			xp:         10,
+		}
+	case EnemySlime:
+		return &LootTable{
+			dropChance: 0.12,
+			maxChance:  0.5,
+			entries:    anyEquipment,
+			perkChance: 0.05,
+			goldMin:    25,
+			goldMax:    75,
+			xp:         15,
+		}
+	case EnemySkelly:
+		return &LootTable{
+			dropChance: 0.15,
+			maxChance:  0.55,
+			entries: []LootEntry{
+				{types: []EquipmentType{EquipmentTypeWeapon}, weight: 3},
+				{types: []EquipmentType{EquipmentTypeArmor}, weight: 2},
+				{types: []EquipmentType{EquipmentTypeAccessory}, weight: 1},
+			},
+			perkChance: 0.08,
+			goldMin:    50,
+			goldMax:    150,
+			xp:         20,
+		}
+	case EnemyEbi:
+		return &LootTable{
+			dropChance: 0.2,
+			maxChance:  0.6,
+			entries: []LootEntry{
+				{equipment: "ebi", weight: 2},
+				{types: []EquipmentType{EquipmentTypeAccessory}, weight: 1},
+				{types: []EquipmentType{EquipmentTypeWeapon, EquipmentTypeArmor}, weight: 1},
+			},
+			qualityFloor: EquipmentQualityUncommon,
+			perkChance:   0.1,
+			goldMin:      100,
+			goldMax:      300,
+			xp:           30,
+		}
+	case EnemyBossRat:
+		return &LootTable{
+			dropChance: 0.5,
+			maxChance:  1,
+			entries:    anyEquipment,
+			uniques: []LootEntry{
+				{unique: "Rat King's Signet"},
+			},
+			qualityFloor: EquipmentQualityUncommon,
+			perkChance:   0.25,
+			goldMin:      375,
+			goldMax:      1125,
+			xp:           50,
+		}
+	case EnemyBossSlime:
+		return &LootTable{
+			dropChance: 0.5,
+			maxChance:  1,
+			entries:    anyEquipment,
+			uniques: []LootEntry{
+				{unique: "Gelatinous Pendant"},
+			},
+			qualityFloor: EquipmentQualityRare,
+			perkChance:   0.3,
+			goldMin:      625,
+			goldMax:      1875,
+			xp:           75,
+		}
+	case EnemyBossSkelly:
+		return &LootTable{
+			dropChance: 0.6,
+			maxChance:  1,
+			entries:    anyEquipment,
+			uniques: []LootEntry{
+				{unique: "Marrowblade"},
+			},
+			qualityFloor: EquipmentQualityRare,
+			perkChance:   0.4,
+			goldMin:      1000,
+			goldMax:      3000,
+			xp:           100,
+		}
+	case EnemyBossEbi:
+		return &LootTable{
+			dropChance: 0.75,
+			maxChance:  1,
+			entries:    anyEquipment,
+			uniques: []LootEntry{
+				{unique: "The Eternal Shrimp"},
+			},
+			qualityFloor: EquipmentQualityEpic,
+			perkChance:   0.5,
+			goldMin:      1500,
+			goldMax:      4500,
+			xp:           150,
+		}
+	default:
+		return &LootTable{}
+	}
+}
+
+// Gold rolls the gold reward for the given enemy level.
+func (lt *LootTable) Gold(level int) int {
+	gold := lt.goldMin
+	if lt.goldMax > lt.goldMin {
+		gold += rand.Intn(lt.goldMax - lt.goldMin + 1)
+	}
+	return int(float64(gold) * levelScale(level))
+}
+
+// XP returns the xp reward for the given enemy level.
+func (lt *LootTable) XP(level int) int {
+	return int(float64(lt.xp) * levelScale(level))
+}
+
+// Roll rolls the table's drops, including any guaranteed drops and uniques.
+func (lt *LootTable) Roll(luck int, storyLevel int) []*Equipment {
+	var drops []*Equipment
+
+	for _, entry := range lt.guaranteed {
+		if eq := lt.rollEntry(&entry, luck, storyLevel); eq != nil {
+			drops = append(drops, eq)
+		}
+	}
+
+	for _, entry := range lt.uniques {
+		if rand.Float64() >= UniqueBossChance {
+			continue
+		}
+		if eq := lt.rollEntry(&entry, luck, storyLevel); eq != nil {
+			drops = append(drops, eq)
+		}
+	}
+
+	if rand.Float64() < math.Min(lt.dropChance+float64(luck)/100.0, lt.maxChance) {
+		if entry := lt.pickEntry(); entry != nil {
+			if eq := lt.rollEntry(entry, luck, storyLevel); eq != nil {
+				drops = append(drops, eq)
+			}
+		}
+	}
+
+	return drops
+}
+
+func (lt *LootTable) pickEntry() *LootEntry {
+	total := 0
+	for _, entry := range lt.entries {
+		total += entry.weight
+	}
+	if total <= 0 {
+		return nil
+	}
+	roll := rand.Intn(total)
+	for i, entry := range lt.entries {
+		roll -= entry.weight
+		if roll < 0 {
+			return &lt.entries[i]
+		}
+	}
+	return nil
+}
+
+func (lt *LootTable) rollEntry(entry *LootEntry, luck int, storyLevel int) *Equipment {
+	if entry.unique != "" {
+		u := GetUniqueItem(entry.unique)
+		if u == nil {
+			fmt.Println("Unknown unique in loot table", entry.unique)
+			return nil
+		}
+		return u.Drop(storyLevel)
+	}
+	perkChance := math.Min(lt.perkChance+float64(luck)/100.0, lt.perkChance+0.2)
+	return RollEquipment(entry.Name(), luck, storyLevel, lt.qualityFloor, perkChance)
+}
+
+// levelScale scales rewards by enemy level, each level past the first adds half again.
+func levelScale(level int) float64 {
+	return 1 + float64(max(0, level-1))*0.5
+}
+
+// RollEquipment creates equipment with quality and perk rolled from luck and story level.
+func RollEquipment(name string, luck int, storyLevel int, qualityFloor EquipmentQuality, perkChance float64) *Equipment {
+	if name == "" {
+		return nil
+	}
+
+	// Determine the initial quality of the equipment based on luck
+	fromLuck := float64(luck) / 10.0
+	fromRoomLevel := float64(storyLevel) / 3.0
+	initialQuality := EquipmentQuality((math.Floor(fromLuck + fromRoomLevel)))
+	if initialQuality < qualityFloor {
+		initialQuality = qualityFloor
+	}
+	if initialQuality > EquipmentQualityLegendary {
+		initialQuality = EquipmentQualityLegendary
+	}
+
+	// Determine perk quality based on luck and room level
+	var perk IPerk = nil
+	if rand.Float64() < perkChance {
+		perkQuality := PerkQuality((math.Floor(fromLuck + fromRoomLevel)))
+		if perkQuality > PerkQualityGodly {
+			perkQuality = PerkQualityGodly
+		}
+		perk = GetRandomPerk(perkQuality)
+	}
+
+	equipment := NewEquipment(name, 1, initialQuality, perk)
+	if equipment == nil {
+		return nil
+	}
+
+	// Level up the equipment based on floor level
+	for i := 0; i < storyLevel; i++ {
+		equipment.LevelUp(EquipmentQualityLegendary)
+	}
+
+	return equipment
+}
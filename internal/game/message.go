@@ -33,8 +33,9 @@ func (mk *MessageKind) Color() color.Color {
 }
 
 type Message struct {
-	kind MessageKind
-	text string
+	kind  MessageKind
+	text  string
+	entry *CombatEntry // Set if the message was created from a combat entry.
 }
 
 var messages []*Message
@@ -57,3 +58,71 @@ func AddMessage(kind MessageKind, text string) *Message {
 func GetMessages() []*Message {
 	return messages
 }
+
+// MessageFilter is used to limit which messages are shown.
+type MessageFilter int
+
+const (
+	MessageFilterAll MessageFilter = iota
+	MessageFilterCombat
+	MessageFilterLoot
+	MessageFilterGood
+	MessageFilterBad
+	MessageFilterDude
+	MessageFilterEnd
+)
+
+func (mf MessageFilter) String() string {
+	switch mf {
+	case MessageFilterAll:
+		return "all"
+	case MessageFilterCombat:
+		return "combat"
+	case MessageFilterLoot:
+		return "loot"
+	case MessageFilterGood:
+		return "good"
+	case MessageFilterBad:
+		return "bad"
+	case MessageFilterDude:
+		return "selected dude"
+	default:
+		return "unknown"
+	}
+}
+
+// Next returns the next filter, wrapping around.
+func (mf MessageFilter) Next() MessageFilter {
+	return (mf + 1) % MessageFilterEnd
+}
+
+// Match returns if the message passes the filter. The dude is only used by MessageFilterDude.
+func (mf MessageFilter) Match(m *Message, d *Dude) bool {
+	switch mf {
+	case MessageFilterCombat:
+		return m.entry != nil && m.entry.Action != CombatActionLoot
+	case MessageFilterLoot:
+		return m.kind == MessageLoot || (m.entry != nil && m.entry.Action == CombatActionLoot)
+	case MessageFilterGood:
+		return m.kind == MessageGood
+	case MessageFilterBad:
+		return m.kind == MessageBad
+	case MessageFilterDude:
+		return d != nil && m.entry != nil && m.entry.dude == d
+	}
+	return true
+}
+
+// GetFilteredMessages returns the messages that pass the filter.
+func GetFilteredMessages(filter MessageFilter, d *Dude) []*Message {
+	if filter == MessageFilterAll {
+		return messages
+	}
+	var filtered []*Message
+	for _, m := range messages {
+		if filter.Match(m, d) {
+			filtered = append(filtered, m)
+		}
+	}
+	return filtered
+}
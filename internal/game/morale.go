@@ -0,0 +1,153 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+const (
+	MoraleMax            = 100.0
+	MoraleFleeThreshold  = 15.0 // Below this dudes flee back down the stairs.
+	MoraleBossThreshold  = 35.0 // Below this dudes refuse to enter a boss room.
+	MoraleHighThreshold  = 80.0 // Above this dudes fight harder.
+	MoraleRallyThreshold = 70.0 // Knights above this rally dudes in the same room.
+	RallyConfidence      = 10   // Confidence a knight needs to rally others.
+)
+
+// BaseMorale returns the morale a dude starts a run with, confident and lightly taxed dudes start higher.
+func (d *Dude) BaseMorale() float64 {
+	return math.Max(0, math.Min(50+float64(d.GetCalculatedStats().confidence)*2+float64(d.taxMood)*3, MoraleMax))
+}
+
+// Morale returns the dude's current morale.
+func (d *Dude) Morale() float64 {
+	return d.morale
+}
+
+// ResetMorale sets the dude's morale back to its base.
+func (d *Dude) ResetMorale() {
+	d.morale = d.BaseMorale()
+	d.dirtyStats = true
+}
+
+// AdjustMorale modifies the dude's morale, clamped to 0 and MoraleMax.
+func (d *Dude) AdjustMorale(amount float64) {
+	d.morale = math.Max(0, math.Min(d.morale+amount, MoraleMax))
+	d.dirtyStats = true
+}
+
+// MoraleString describes the dude's current mood.
+func (d *Dude) MoraleString() string {
+	switch {
+	case d.morale >= MoraleHighThreshold:
+		return "heroic"
+	case d.morale >= MoraleBossThreshold:
+		return "steady"
+	case d.morale >= MoraleFleeThreshold:
+		return "shaken"
+	default:
+		return "terrified"
+	}
+}
+
+// MoraleDamageMultiplier returns the damage multiplier granted by high morale.
+func (d *Dude) MoraleDamageMultiplier() float64 {
+	if d.morale >= MoraleHighThreshold {
+		return 1.2
+	}
+	return 1
+}
+
+// UpdateMorale adjusts the dude's morale in response to an event.
+func (d *Dude) UpdateMorale(e Event) {
+	switch e := e.(type) {
+	case EventDudeHit:
+		if e.amount <= 0 || d.IsDead() {
+			return
+		}
+		// Getting hit when nearly dead is much scarier.
+		if d.stats.currentHp*4 < d.GetCalculatedStats().totalHp {
+			d.AdjustMorale(-8)
+		} else {
+			d.AdjustMorale(-1)
+		}
+	case EventStartBoss:
+		d.AdjustMorale(-10)
+	case EventEndBoss:
+		d.AdjustMorale(30)
+	case EventEnterRoom:
+		// Morale slowly settles back towards the dude's base.
+		base := d.BaseMorale()
+		if d.morale < base {
+			d.AdjustMorale(math.Min(2, base-d.morale))
+		} else if d.morale > base {
+			d.AdjustMorale(-math.Min(2, d.morale-base))
+		}
+	}
+	if d.ShouldFlee() {
+		d.Flee()
+	}
+}
+
+// WitnessDeath lowers the dude's morale for seeing another dude die.
+func (d *Dude) WitnessDeath(dead *Dude) {
+	if d == dead || d.IsDead() {
+		return
+	}
+	if d.room != nil && d.room == dead.room {
+		d.AdjustMorale(-20)
+	} else {
+		d.AdjustMorale(-10)
+	}
+	if d.ShouldFlee() {
+		d.Flee()
+	}
+}
+
+// ShouldFlee returns if the dude's morale is low enough to run away.
+func (d *Dude) ShouldFlee() bool {
+	if d.IsDead() || d.morale >= MoraleFleeThreshold {
+		return false
+	}
+	// Only dudes that are walking about can flee, boss fights and stairs are committed to.
+	return d.activity == Moving || d.activity == Centering
+}
+
+// RefusesBoss returns if the dude is too scared to enter a boss room.
+func (d *Dude) RefusesBoss() bool {
+	return d.morale < MoraleBossThreshold
+}
+
+// Flee makes the dude abandon any fight and head back to the stairs.
+func (d *Dude) Flee() {
+	d.enemy = nil
+	d.floatingText("*flee*", color.NRGBA{200, 200, 255, 200}, 60, 0.5)
+	AddMessage(
+		MessageBad,
+		fmt.Sprintf("%s lost their nerve and fled!", d.name),
+	)
+	// Already standing in the stairwell, so just go.
+	if d.room != nil && d.room.index == 7 {
+		d.SetActivity(GoingDown)
+	} else {
+		d.SetActivity(Leaving)
+	}
+}
+
+// Rally lets confident knights raise the morale of dudes in the same room.
+func (r *Room) Rally() {
+	for _, knight := range r.dudes {
+		if knight.IsDead() || !knight.profession.Is(Knight) {
+			continue
+		}
+		if knight.morale < MoraleRallyThreshold || knight.GetCalculatedStats().confidence < RallyConfidence {
+			continue
+		}
+		for _, d := range r.dudes {
+			if d != knight && !d.IsDead() {
+				d.AdjustMorale(3)
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	PartyDelayStep     = 15 // Seconds added to a party's departure delay per step.
+	PartyMaxDelay      = 60
+	PartyMaxStory      = 3  // Highest story a party can be set to wait on.
+	PartyMaxNameLength = 12 // Longest name the player can give a party.
+)
+
+// PartyColors are the color tags of each party, the first party is untinted.
+var PartyColors = []color.NRGBA{
+	{255, 255, 255, 255},
+	{255, 150, 150, 255},
+	{150, 190, 255, 255},
+	{160, 255, 160, 255},
+}
+
+// PartyName returns the default name of the party at the given index.
+func PartyName(index int) string {
+	return fmt.Sprintf("Party %c", 'A'+index)
+}
+
+// PartyTint returns the color scale dudes in the party are tinted with.
+func PartyTint(index int) ebiten.ColorScale {
+	cs := ebiten.ColorScale{}
+	if index > 0 && index < len(PartyColors) {
+		cs.ScaleWithColor(PartyColors[index])
+	}
+	return cs
+}
+
+// PartyDeparture is what a party waits on before setting out into the tower.
+type PartyDeparture int
+
+const (
+	PartyDepartAtOnce PartyDeparture = iota
+	PartyDepartAfterDelay
+	PartyDepartAfterClear // After another party clears a story.
+)
+
+// Party is a group of dudes that set out into the tower together.
+type Party struct {
+	name      string
+	departure PartyDeparture
+	delay     int  // Seconds to wait after the run starts.
+	after     int  // Index of the party that has to clear a story first.
+	story     int  // Story the other party has to clear, counting from 1.
+	departed  bool // Whether the party has set out this run.
+	reached   int  // Stories the party has cleared this run.
+}
+
+// NewParties creates a party for each party color, all departing at once.
+func NewParties() []*Party {
+	parties := make([]*Party, 0, len(PartyColors))
+	for i := range PartyColors {
+		parties = append(parties, &Party{name: PartyName(i)})
+	}
+	return parties
+}
+
+// Name returns the party's name.
+func (p *Party) Name() string {
+	return p.name
+}
+
+// Reset readies the party for a new run.
+func (p *Party) Reset() {
+	p.departed = false
+	p.reached = 0
+}
+
+// Clear notes that the party made it through the given number of stories.
+func (p *Party) Clear(stories int) {
+	p.reached = max(p.reached, stories)
+}
+
+// Ready returns if the party should set out, given the ticks since the run started.
+func (p *Party) Ready(ticks int, parties []*Party) bool {
+	switch p.departure {
+	case PartyDepartAfterDelay:
+		return ticks >= p.delay*ebiten.TPS()
+	case PartyDepartAfterClear:
+		if p.after < 0 || p.after >= len(parties) {
+			return true
+		}
+		return parties[p.after].reached >= p.story
+	default:
+		return true
+	}
+}
+
+// DepartureString describes when the party sets out.
+func (p *Party) DepartureString(parties []*Party) string {
+	switch p.departure {
+	case PartyDepartAfterDelay:
+		return fmt.Sprintf("departs after %ds", p.delay)
+	case PartyDepartAfterClear:
+		after := PartyName(p.after)
+		if p.after >= 0 && p.after < len(parties) {
+			after = parties[p.after].Name()
+		}
+		return fmt.Sprintf("departs after %s clears story %d", after, p.story)
+	default:
+		return "departs at once"
+	}
+}
+
+// CycleDeparture steps the party to the next departure option: at once, then each delay, then after each other party clears each story.
+func (p *Party) CycleDeparture(self int, count int) {
+	switch p.departure {
+	case PartyDepartAtOnce:
+		p.departure = PartyDepartAfterDelay
+		p.delay = PartyDelayStep
+	case PartyDepartAfterDelay:
+		if p.delay < PartyMaxDelay {
+			p.delay += PartyDelayStep
+			return
+		}
+		p.departure = PartyDepartAfterClear
+		p.after = -1
+		p.story = PartyMaxStory
+		fallthrough
+	case PartyDepartAfterClear:
+		if p.story < PartyMaxStory {
+			p.story++
+			return
+		}
+		p.story = 1
+		p.after++
+		if p.after == self {
+			p.after++
+		}
+		if p.after >= count {
+			p.departure = PartyDepartAtOnce
+		}
+	}
+}
+
+// Party returns the index of the dude's party.
+func (d *Dude) Party() int {
+	return d.party
+}
+
+// SetParty moves the dude into the party at the given index.
+func (d *Dude) SetParty(index int) {
+	d.party = index
+	d.SyncTint()
+	d.dirtyStats = true
+	d.dirtyEquipment = true
+}
+
+// CycleParty moves the dude into the next party.
+func (d *Dude) CycleParty() {
+	d.SetParty((d.party + 1) % len(PartyColors))
+}
+
+// SyncTint tints the dude by its profession and party.
+func (d *Dude) SyncTint() {
+	d.stack.ColorScale = d.profession.Tint()
+	d.stack.ColorScale.ScaleWithColorScale(PartyTint(d.party))
+}
+
+// PartyDudes returns the living dudes in the party at the given index.
+func (g *Game) PartyDudes(index int) []*Dude {
+	var dudes []*Dude
+	for _, d := range g.dudes {
+		if d.party == index && !d.IsDead() {
+			dudes = append(dudes, d)
+		}
+	}
+	return dudes
+}
+
+// SyncParties shows the parties' dude counts and departures.
+func (g *Game) SyncParties() {
+	counts := make([]int, len(g.parties))
+	for i := range g.parties {
+		counts[i] = len(g.PartyDudes(i))
+	}
+	g.ui.partyPanel.SetParties(g.parties, counts)
+	g.ui.dudeInfoPanel.parties = g.parties
+	g.ui.dudeInfoPanel.SyncDude()
+}
+
+// PartiesPending returns if any party with living dudes has yet to set out.
+func (g *Game) PartiesPending() bool {
+	for i, p := range g.parties {
+		if !p.departed && len(g.PartyDudes(i)) > 0 {
+			return true
+		}
+	}
+	return false
+}
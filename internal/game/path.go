@@ -0,0 +1,152 @@
+package game
+
+import (
+	"math"
+)
+
+// PathNodeKind is what a spot in a story's path graph marks.
+type PathNodeKind int
+
+const (
+	PathNodeRoomCenter PathNodeKind = iota
+	PathNodeWaiting                 // Where dudes gather before a boss room.
+	PathNodeStairs                  // The entrance to the stairs up.
+	PathNodePortal
+)
+
+// PathNodeSkip is how close a node can be and still count as already passed when looking for the next one.
+const PathNodeSkip = 0.01
+
+// PathNode is a spot on a story that dudes walk between.
+type PathNode struct {
+	kind     PathNodeKind
+	room     *Room
+	angle    float64 // Angle from the center of the tower.
+	distance float64 // Distance from the center of the tower.
+	next     *PathNode
+	prev     *PathNode
+}
+
+// Waypoint returns a waypoint at the node, offset outwards by the dude's variation.
+func (n *PathNode) Waypoint(variation float64) Waypoint {
+	return Waypoint{angle: n.angle, distance: n.distance + variation}
+}
+
+// PathGraph connects the spots dudes walk between on a story. The ring runs counter-clockwise through the rooms in order, ending at the stairs.
+type PathGraph struct {
+	ring   []*PathNode
+	portal *PathNode
+}
+
+// NewPathGraph builds the path graph for the story's current rooms.
+func NewPathGraph(s *Story) *PathGraph {
+	pg := &PathGraph{}
+	var last *Room
+	for i, room := range s.rooms {
+		if room == nil || room == last {
+			continue
+		}
+		last = room
+		center := math.Pi/2 - s.GetRoomCenterRad(i)
+		if room.kind == Boss {
+			// Matches where Story.Update starts making dudes wait.
+			pg.add(&PathNode{kind: PathNodeWaiting, room: room, angle: normalizeAngle(center + math.Pi/4), distance: RoomPath})
+		}
+		kind := PathNodeRoomCenter
+		if room.kind == Stairs {
+			kind = PathNodeStairs
+		}
+		pg.add(&PathNode{kind: kind, room: room, angle: normalizeAngle(center), distance: RoomPath})
+	}
+	if len(pg.ring) > 0 {
+		first, end := pg.ring[0], pg.ring[len(pg.ring)-1]
+		first.prev = end
+		end.next = first
+	}
+
+	if s.portalStack != nil {
+		pg.portal = &PathNode{kind: PathNodePortal, angle: s.PortalRotation(), distance: PortalDistance}
+		if len(s.rooms) > 6 {
+			pg.portal.prev = pg.RoomCenter(s.rooms[6])
+		}
+	}
+	return pg
+}
+
+func (pg *PathGraph) add(n *PathNode) {
+	if len(pg.ring) > 0 {
+		prev := pg.ring[len(pg.ring)-1]
+		prev.next = n
+		n.prev = prev
+	}
+	pg.ring = append(pg.ring, n)
+}
+
+// Ahead returns the next node along the ring counter-clockwise from the given angle.
+func (pg *PathGraph) Ahead(angle float64) *PathNode {
+	var best *PathNode
+	bestDistance := math.Inf(1)
+	for _, n := range pg.ring {
+		d := wrapAngle(angle - n.angle)
+		if d > PathNodeSkip && d < bestDistance {
+			best, bestDistance = n, d
+		}
+	}
+	return best
+}
+
+// Behind returns the previous node along the ring clockwise from the given angle.
+func (pg *PathGraph) Behind(angle float64) *PathNode {
+	var best *PathNode
+	bestDistance := math.Inf(1)
+	for _, n := range pg.ring {
+		d := wrapAngle(n.angle - angle)
+		if d > PathNodeSkip && d < bestDistance {
+			best, bestDistance = n, d
+		}
+	}
+	return best
+}
+
+// RoomCenter returns the center node of the room.
+func (pg *PathGraph) RoomCenter(r *Room) *PathNode {
+	for _, n := range pg.ring {
+		if n.room == r && n.kind != PathNodeWaiting {
+			return n
+		}
+	}
+	return nil
+}
+
+// Stairs returns the stairs entrance node.
+func (pg *PathGraph) Stairs() *PathNode {
+	for _, n := range pg.ring {
+		if n.kind == PathNodeStairs {
+			return n
+		}
+	}
+	return nil
+}
+
+// Portal returns the portal node, if the story has a portal.
+func (pg *PathGraph) Portal() *PathNode {
+	return pg.portal
+}
+
+// wrapAngle wraps the angle to [0, 2π).
+func wrapAngle(a float64) float64 {
+	a = math.Mod(a, math.Pi*2)
+	if a < 0 {
+		a += math.Pi * 2
+	}
+	return a
+}
+
+// normalizeAngle wraps the angle to (-π, π].
+func normalizeAngle(a float64) float64 {
+	a = wrapAngle(a)
+	if a > math.Pi {
+		a -= math.Pi * 2
+	}
+	return a
+}
@@ -217,18 +217,22 @@ func (p PerkStatBoost) Description() string {
 	return fmt.Sprintf("Boosts %s stat by %d", p.stat, p.amount())
 }
 
+// Check does nothing, the boost is added by GetCalculatedStats so it always matches the perk's current quality.
 func (p PerkStatBoost) Check(e Event) bool {
-	switch e := e.(type) {
-	case EventEquip:
-		e.dude.Stats().ModifyStat(p.stat, p.amount())
-		return false
-	case EventUnequip:
-		e.dude.Stats().ModifyStat(p.stat, p.amount())
-		return false
-	}
 	return false
 }
 
+// perkStats returns the stats the equipment's stat boost perks give.
+func perkStats(e *Equipment) *Stats {
+	stats := &Stats{}
+	for _, p := range e.Perks() {
+		if sb, ok := p.(PerkStatBoost); ok {
+			stats.ModifyStat(sb.stat, sb.amount())
+		}
+	}
+	return stats
+}
+
 // PerkHeal heals dude based on wisdom when entering a room.
 type PerkHealOnRoomEnter struct {
 	*Perk
@@ -329,7 +333,7 @@ func (p PerkHealOnGoldGain) Check(e Event) bool {
 	return false
 }
 
-// PerkHealOnGoldGain heals dude when they get gold
+// PerkHealOnGoldLoss heals dude when they lose gold
 type PerkHealOnGoldLoss struct {
 	*Perk
 }
@@ -352,14 +356,14 @@ func (p PerkHealOnGoldLoss) Description() string {
 
 func (p PerkHealOnGoldLoss) Check(e Event) bool {
 	switch e := e.(type) {
-	case EventGoldGain:
+	case EventGoldLoss:
 		e.dude.Heal(p.amount())
 		return true
 	}
 	return false
 }
 
-// PerkStickyFingers reduces gold loss
+// PerkStickyFingers hangs on to some of the gold being lost
 type PerkStickyFingers struct {
 	*Perk
 }
@@ -383,7 +387,7 @@ func (p PerkStickyFingers) Description() string {
 func (p PerkStickyFingers) Check(e Event) bool {
 	switch e := e.(type) {
 	case EventGoldLoss:
-		e.dude.UpdateGold(int(float64(-e.amount) * p.amount()))
+		e.dude.RefundGold(int(float64(e.amount) * p.amount()))
 		return true
 	}
 	return false
@@ -477,14 +481,269 @@ func (p PerkHealOnCrit) Check(e Event) bool {
 	return false
 }
 
-func GetRandomPerk(quality PerkQuality) IPerk {
-	// Randomly select a perk
-	perk := &Perk{
-		quality: quality,
+// PerkThorns hurts whatever hits the dude.
+type PerkThorns struct {
+	*Perk
+}
+
+func (p PerkThorns) amount(damage int) int {
+	return max(1, damage*int(p.quality+1)/5)
+}
+
+func (p PerkThorns) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkThorns) String() string {
+	return "Thorns"
+}
+
+func (p PerkThorns) Description() string {
+	return fmt.Sprintf("Reflects %d%% of damage taken back at the attacker", int(p.quality+1)*20)
+}
+
+func (p PerkThorns) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventDudeHit:
+		if e.enemy == nil || e.amount <= 0 || e.dude.IsDead() {
+			return false
+		}
+		if dealt := e.enemy.Wound(p.amount(e.amount)); dealt > 0 {
+			AddCombatEntry(NewCombatEntry(e.dude, CombatActionHit, e.enemy.Name(), dealt))
+			return true
+		}
 	}
+	return false
+}
 
-	// Set of all perks
-	perkList := []IPerk{
+// PerkSecondWind heals the dude as a boss fight begins.
+type PerkSecondWind struct {
+	*Perk
+}
+
+func (p PerkSecondWind) amount() int {
+	return int(p.quality+1) * 8
+}
+
+func (p PerkSecondWind) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkSecondWind) String() string {
+	return "Second Wind"
+}
+
+func (p PerkSecondWind) Description() string {
+	return fmt.Sprintf("Heals dude for %d when a boss fight starts", p.amount())
+}
+
+func (p PerkSecondWind) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventStartBoss:
+		e.dude.Heal(p.amount())
+		return true
+	}
+	return false
+}
+
+// PerkBossSlayer hits bosses harder.
+type PerkBossSlayer struct {
+	*Perk
+}
+
+func (p PerkBossSlayer) amount(damage int) int {
+	return max(1, damage*int(p.quality+1)/10)
+}
+
+func (p PerkBossSlayer) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkBossSlayer) String() string {
+	return "Boss Slayer"
+}
+
+func (p PerkBossSlayer) Description() string {
+	return fmt.Sprintf("Deals %d%% extra damage to bosses", int(p.quality+1)*10)
+}
+
+func (p PerkBossSlayer) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventEnemyHit:
+		if !e.enemy.IsBoss() {
+			return false
+		}
+		if dealt := e.enemy.Wound(p.amount(e.amount)); dealt > 0 {
+			AddCombatEntry(NewCombatEntry(e.dude, CombatActionHit, e.enemy.Name(), dealt))
+			return true
+		}
+	}
+	return false
+}
+
+// PerkLifesteal heals the dude for some of the damage they deal.
+type PerkLifesteal struct {
+	*Perk
+}
+
+func (p PerkLifesteal) amount(damage int) int {
+	return max(1, damage*int(p.quality+1)/10)
+}
+
+func (p PerkLifesteal) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkLifesteal) String() string {
+	return "Lifesteal"
+}
+
+func (p PerkLifesteal) Description() string {
+	return fmt.Sprintf("Heals dude for %d%% of the damage they deal", int(p.quality+1)*10)
+}
+
+func (p PerkLifesteal) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventEnemyHit:
+		return e.dude.Heal(p.amount(e.amount)) > 0
+	}
+	return false
+}
+
+// PerkShieldBash gets a hit in even when the dude misses.
+type PerkShieldBash struct {
+	*Perk
+}
+
+func (p PerkShieldBash) amount() int {
+	return int(p.quality+1) * 2
+}
+
+func (p PerkShieldBash) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkShieldBash) String() string {
+	return "Shield Bash"
+}
+
+func (p PerkShieldBash) Description() string {
+	return fmt.Sprintf("Bashes for %d damage when dude misses", p.amount())
+}
+
+func (p PerkShieldBash) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventDudeMiss:
+		if e.enemy == nil {
+			return false
+		}
+		if dealt := e.enemy.Wound(p.amount()); dealt > 0 {
+			AddCombatEntry(NewCombatEntry(e.dude, CombatActionHit, e.enemy.Name(), dealt))
+			return true
+		}
+	}
+	return false
+}
+
+// PerkRoomClear rewards the dude with xp for making it through a room.
+type PerkRoomClear struct {
+	*Perk
+}
+
+func (p PerkRoomClear) amount() int {
+	return int(p.quality+1) * 2
+}
+
+func (p PerkRoomClear) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkRoomClear) String() string {
+	return "Trailblazer"
+}
+
+func (p PerkRoomClear) Description() string {
+	return fmt.Sprintf("Gains %d xp when reaching the end of a room", p.amount())
+}
+
+func (p PerkRoomClear) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventEndRoom:
+		if e.dude.IsDead() {
+			return false
+		}
+		e.dude.AddXP(p.amount())
+		return true
+	}
+	return false
+}
+
+// PerkHealOnWait heals the dude while they wait for the party.
+type PerkHealOnWait struct {
+	*Perk
+}
+
+func (p PerkHealOnWait) amount() int {
+	return int(p.quality+1) * 3
+}
+
+func (p PerkHealOnWait) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkHealOnWait) String() string {
+	return "Patience"
+}
+
+func (p PerkHealOnWait) Description() string {
+	return fmt.Sprintf("Heals dude for %d when they stop to wait", p.amount())
+}
+
+func (p PerkHealOnWait) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventWaitRoom:
+		return e.dude.Heal(p.amount()) > 0
+	}
+	return false
+}
+
+// PerkVictoryRush picks the dude back up after a boss falls.
+type PerkVictoryRush struct {
+	*Perk
+}
+
+func (p PerkVictoryRush) amount() int {
+	return int(p.quality+1) * 10
+}
+
+func (p PerkVictoryRush) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkVictoryRush) String() string {
+	return "Victory Rush"
+}
+
+func (p PerkVictoryRush) Description() string {
+	return fmt.Sprintf("Heals dude for %d and lifts morale after beating a boss", p.amount())
+}
+
+func (p PerkVictoryRush) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventEndBoss:
+		if e.dude.IsDead() {
+			return false
+		}
+		e.dude.Heal(p.amount())
+		e.dude.AdjustMorale(float64(p.amount()))
+		return true
+	}
+	return false
+}
+
+// allPerks returns one of every perk that can be rolled, sharing the given base perk.
+func allPerks(perk *Perk) []IPerk {
+	return []IPerk{
 		PerkFindGold{perk},
 		PerkStatBoost{perk, StatStrength},
 		PerkStatBoost{perk, StatWisdom},
@@ -498,13 +757,54 @@ func GetRandomPerk(quality PerkQuality) IPerk {
 		PerkHealOnDodge{perk},
 		PerkHealOnCrit{perk},
 		PerkGoldBoost{perk},
+		PerkStickyFingers{perk},
+		PerkHealOnGoldLoss{perk},
+		PerkThorns{perk},
+		PerkSecondWind{perk},
+		PerkBossSlayer{perk},
+		PerkLifesteal{perk},
+		PerkShieldBash{perk},
+		PerkRoomClear{perk},
+		PerkHealOnWait{perk},
+		PerkVictoryRush{perk},
+	}
+}
 
-		// Curse dependent perks
-		// PerkStickyFingers{perk},
-		// PerkHealOnGoldLoss{perk},
+func GetRandomPerk(quality PerkQuality) IPerk {
+	// Randomly select a perk
+	perk := &Perk{
+		quality: quality,
 	}
 
+	// Set of all perks
+	perkList := allPerks(perk)
+
 	// Randomly select a perk
 	index := rand.Intn(len(perkList))
 	return perkList[index]
 }
+
+// GetPerkByName returns a new perk matching the name and, for stat boosts, the stat. Signature perks are found too. Returns nil if there is no such perk.
+func GetPerkByName(name string, stat Stat, quality PerkQuality) IPerk {
+	perk := &Perk{
+		quality: quality,
+	}
+	for _, p := range append(allPerks(perk), signaturePerks(perk)...) {
+		if p.String() != name {
+			continue
+		}
+		if sb, ok := p.(PerkStatBoost); ok && sb.stat != stat {
+			continue
+		}
+		return p
+	}
+	return nil
+}
+
+// PerkStat returns the stat a perk boosts, if any.
+func PerkStat(p IPerk) Stat {
+	if sb, ok := p.(PerkStatBoost); ok {
+		return sb.stat
+	}
+	return ""
+}
@@ -0,0 +1,188 @@
+package game
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// PriorityWeight is how much more a priority stat counts for when weighing an upgrade.
+const PriorityWeight = 3
+
+// PolicyProfessions are the professions priority stats are set for, promoted dudes use the stats of the profession they were promoted from.
+var PolicyProfessions = []ProfessionKind{Vagabond, Knight, Cleric, Ranger}
+
+// PolicyStats are the stats that can be prioritized.
+var PolicyStats = []Stat{StatStrength, StatWisdom, StatDefense, StatAgility, StatConfidence, StatLuck, StatMaxHP}
+
+// LootPolicy is the player's rules for auto-equipping and selling loot, kept in the profile between runs.
+type LootPolicy struct {
+	PriorityStats  map[string][]Stat `json:"priorityStats"` // By profession name.
+	KeepPerks      []string          `json:"keepPerks"`     // Perk names, such as "Find Gold", never sold or swapped out.
+	MaxSellQuality EquipmentQuality  `json:"maxSellQuality"`
+	Spread         bool              `json:"spread"` // Give each dude one upgrade at a time rather than the highest level dudes first pick.
+}
+
+// NewLootPolicy creates a policy that behaves like plain auto-equip and sell all.
+func NewLootPolicy() *LootPolicy {
+	return &LootPolicy{
+		PriorityStats:  make(map[string][]Stat),
+		MaxSellQuality: EquipmentQualityLegendary,
+	}
+}
+
+// PolicyPerks returns the perk names that can be kept.
+func PolicyPerks() []string {
+	var names []string
+	for _, p := range allPerks(&Perk{}) {
+		if name := p.String(); !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Priorities returns the priority stats for the profession.
+func (lp *LootPolicy) Priorities(pk ProfessionKind) []Stat {
+	base := pk.Base()
+	return lp.PriorityStats[base.String()]
+}
+
+// TogglePriority adds or removes a priority stat for the profession.
+func (lp *LootPolicy) TogglePriority(pk ProfessionKind, stat Stat) {
+	base := pk.Base()
+	name := base.String()
+	stats := lp.PriorityStats[name]
+	if i := slices.Index(stats, stat); i >= 0 {
+		lp.PriorityStats[name] = slices.Delete(stats, i, i+1)
+	} else {
+		lp.PriorityStats[name] = append(stats, stat)
+	}
+}
+
+// Keeps returns if the equipment has a perk the policy keeps.
+func (lp *LootPolicy) Keeps(e *Equipment) bool {
+	if e == nil {
+		return false
+	}
+	for _, p := range e.Perks() {
+		if slices.Contains(lp.KeepPerks, p.String()) {
+			return true
+		}
+	}
+	return false
+}
+
+// ToggleKeepPerk adds or removes a kept perk.
+func (lp *LootPolicy) ToggleKeepPerk(name string) {
+	if i := slices.Index(lp.KeepPerks, name); i >= 0 {
+		lp.KeepPerks = slices.Delete(lp.KeepPerks, i, i+1)
+	} else {
+		lp.KeepPerks = append(lp.KeepPerks, name)
+	}
+}
+
+// CycleMaxSellQuality raises the highest quality that gets sold, wrapping back around to common.
+func (lp *LootPolicy) CycleMaxSellQuality() {
+	lp.MaxSellQuality++
+	if lp.MaxSellQuality > EquipmentQualityLegendary {
+		lp.MaxSellQuality = EquipmentQualityCommon
+	}
+}
+
+// Sellable returns if selling everything should sell the equipment. Consumables are always kept for the dudes' belts, and uniques are too rare to sell off in bulk.
+func (lp *LootPolicy) Sellable(e *Equipment) bool {
+	return !e.IsConsumable() && !e.IsUnique() && !lp.Keeps(e) && e.quality <= lp.MaxSellQuality
+}
+
+// Gain weighs how much the dude gains from the equipment, counting priority stats extra.
+func (lp *LootPolicy) Gain(d *Dude, eq *Equipment) int {
+	delta := CompareEquipment(d, eq).Delta()
+	gain := statScore(delta)
+	for _, stat := range lp.Priorities(d.Profession()) {
+		gain += (PriorityWeight - 1) * statValue(delta, stat)
+	}
+	return gain
+}
+
+// ShouldEquip returns if auto-equip should put the equipment on the dude. Without priority stats this is the dude's own judgement.
+func (lp *LootPolicy) ShouldEquip(d *Dude, eq *Equipment) bool {
+	if !d.CanEquip(eq) {
+		return false
+	}
+	if _, worn := d.SlotOf(eq); worn {
+		return false
+	}
+	if eq.IsConsumable() {
+		return d.ShouldEquip(eq)
+	}
+	// Never give up a kept perk for an item without one.
+	if !lp.Keeps(eq) {
+		for _, e := range d.Displaced(eq, d.SlotFor(eq)) {
+			if lp.Keeps(e) {
+				return false
+			}
+		}
+	}
+	if len(lp.Priorities(d.Profession())) == 0 {
+		return d.ShouldEquip(eq)
+	}
+	return lp.Gain(d, eq) > 0
+}
+
+// DudeOrder returns the dudes in the order they pick equipment: highest level first, or lowest first when spreading upgrades out.
+func (lp *LootPolicy) DudeOrder(dudes []*Dude) []*Dude {
+	sorted := SortDudes(SortPropertyLevel, dudes)
+	if !lp.Spread {
+		slices.Reverse(sorted)
+	}
+	return sorted
+}
+
+// PrioritiesString describes the priority stats for the profession.
+func (lp *LootPolicy) PrioritiesString(pk ProfessionKind) string {
+	stats := lp.Priorities(pk)
+	if len(stats) == 0 {
+		return "any"
+	}
+	var names []string
+	for _, s := range stats {
+		names = append(names, string(s))
+	}
+	return strings.Join(names, ", ")
+}
+
+// SpreadString describes who gets upgrades first.
+func (lp *LootPolicy) SpreadString() string {
+	if lp.Spread {
+		return "Upgrades: spread out"
+	}
+	return "Upgrades: top dudes first"
+}
+
+// SellString describes the highest quality that gets sold.
+func (lp *LootPolicy) SellString() string {
+	return fmt.Sprintf("Sell up to %s", lp.MaxSellQuality)
+}
+
+// statValue returns the amount of a single stat.
+func statValue(s *Stats, stat Stat) int {
+	switch stat {
+	case StatStrength:
+		return s.strength
+	case StatWisdom:
+		return s.wisdom
+	case StatDefense:
+		return s.defense
+	case StatAgility:
+		return s.agility
+	case StatConfidence:
+		return s.confidence
+	case StatLuck:
+		return s.luck
+	case StatMaxHP:
+		return s.totalHp
+	default:
+		return 0
+	}
+}
@@ -1,7 +1,10 @@
 package game
 
 import (
+	"fmt"
 	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
 )
 
 // ProfessionKind is an enumeration of the different kinds of Professions a dude can have
@@ -17,11 +20,109 @@ func (p *ProfessionKind) String() string {
 		return "Cleric"
 	case Ranger:
 		return "Ranger"
+	case Paladin:
+		return "Paladin"
+	case Bishop:
+		return "Bishop"
+	case Swashbuckler:
+		return "Swashbuckler"
+	case Sharpshooter:
+		return "Sharpshooter"
 	default:
 		return "Unknown"
 	}
 }
 
+// Base returns the profession a promoted profession was promoted from.
+func (p ProfessionKind) Base() ProfessionKind {
+	switch p {
+	case Paladin:
+		return Knight
+	case Bishop:
+		return Cleric
+	case Swashbuckler:
+		return Vagabond
+	case Sharpshooter:
+		return Ranger
+	default:
+		return p
+	}
+}
+
+// Is returns if the profession is the other profession or was promoted from it.
+func (p ProfessionKind) Is(other ProfessionKind) bool {
+	return p == other || p.Base() == other
+}
+
+// Promotion returns the profession this one can be promoted to, if any.
+func (p ProfessionKind) Promotion() (ProfessionKind, bool) {
+	switch p {
+	case Knight:
+		return Paladin, true
+	case Cleric:
+		return Bishop, true
+	case Vagabond:
+		return Swashbuckler, true
+	case Ranger:
+		return Sharpshooter, true
+	default:
+		return "", false
+	}
+}
+
+// PromotionLevel is the level a dude must reach before being promoted.
+const PromotionLevel = 10
+
+// PromotionRequirement describes what a dude must do to be promoted from this profession.
+func (p ProfessionKind) PromotionRequirement() string {
+	switch p {
+	case Knight:
+		return "slay a boss"
+	case Cleric:
+		return "heal 1000 hp"
+	case Vagabond:
+		return "land 25 crits"
+	case Ranger:
+		return "slay 50 enemies"
+	default:
+		return ""
+	}
+}
+
+// CanPromote returns if the dude meets the requirements to promote out of this profession.
+func (p ProfessionKind) CanPromote(d *Dude) bool {
+	if _, ok := p.Promotion(); !ok || d.Level() < PromotionLevel {
+		return false
+	}
+	switch p {
+	case Knight:
+		return d.bossKills >= 1
+	case Cleric:
+		return d.healingDone >= 1000
+	case Vagabond:
+		return d.crits >= 25
+	case Ranger:
+		return d.kills >= 50
+	}
+	return false
+}
+
+// Tint returns the color scale applied to a dude's skin for the profession.
+func (p ProfessionKind) Tint() ebiten.ColorScale {
+	cs := ebiten.ColorScale{}
+	switch p {
+	case Paladin:
+		cs.Scale(1, 0.9, 0.6, 1)
+	case Bishop:
+		cs.Scale(0.9, 0.9, 1.2, 1)
+	case Swashbuckler:
+		cs.Scale(1, 0.7, 0.7, 1)
+	case Sharpshooter:
+		cs.Scale(0.7, 1, 0.7, 1)
+	}
+	return cs
+}
+
 const (
 	// Medium defense, medium attack, medium hp
 	Vagabond ProfessionKind = "vagabond"
@@ -34,6 +135,18 @@ const (
 
 	// Medium defense, high attack, low hp *ranged*
 	Ranger ProfessionKind = "ranger"
+
+	// Promoted knight, holy and sturdy
+	Paladin ProfessionKind = "paladin"
+
+	// Promoted cleric, heals even better
+	Bishop ProfessionKind = "bishop"
+
+	// Promoted vagabond, quick and lucky
+	Swashbuckler ProfessionKind = "swashbuckler"
+
+	// Promoted ranger, hits very hard
+	Sharpshooter ProfessionKind = "sharpshooter"
 )
 
 // A profession defines a dude's abilities.
@@ -57,7 +170,7 @@ func WeightedRandomProfessionKind(dudes []*Dude) ProfessionKind {
 
 	// Count the number of each profession
 	for _, d := range dudes {
-		professionCount[d.profession]++
+		professionCount[d.profession.Base()]++
 	}
 
 	// Calculate weights (lower frequency = higher weight)
@@ -133,6 +246,14 @@ func NewProfession(kind ProfessionKind, level int) *Profession {
 				NewEquipment("Leather", 1, EquipmentQualityCommon, nil),
 			},
 		}
+	case Paladin, Bishop, Swashbuckler, Sharpshooter:
+		// Promoted professions start like their base profession with better growth.
+		profession := NewProfession(kind.Base(), level)
+		profession.kind = kind
+		base := kind.Base()
+		profession.description = fmt.Sprintf("A promoted %s", base.String())
+		profession.startingStats = *getStartingStats(kind, 1)
+		return profession
 	}
 	return nil
 }
@@ -153,9 +274,14 @@ func (p *Profession) StartingEquipment() []*Equipment {
 // Professions are created using their level change modifiers to stats and a given level
 // Then they level up and apply the changes
 func getStartingStats(kind ProfessionKind, level int) *Stats {
+	return NewStats(getLevelUpChange(kind, level), false)
+}
+
+// getLevelUpChange returns the per level stat growth of a profession.
+func getLevelUpChange(kind ProfessionKind, level int) *Stats {
 	switch kind {
 	case Knight:
-		return NewStats(&Stats{
+		return &Stats{
 			level:      level,
 			totalHp:    7,
 			strength:   2,
@@ -164,9 +290,9 @@ func getStartingStats(kind ProfessionKind, level int) *Stats {
 			agility:    1,
 			confidence: 5, // balls get bigger
 			luck:       0,
-		}, false)
+		}
 	case Cleric:
-		return NewStats(&Stats{
+		return &Stats{
 			level:      level,
 			totalHp:    5,
 			strength:   1,
@@ -175,9 +301,9 @@ func getStartingStats(kind ProfessionKind, level int) *Stats {
 			agility:    2,
 			confidence: 1, // balls get smaller
 			luck:       0,
-		}, false)
+		}
 	case Vagabond:
-		return NewStats(&Stats{
+		return &Stats{
 			level:      level,
 			totalHp:    7,
 			strength:   3,
@@ -186,9 +312,9 @@ func getStartingStats(kind ProfessionKind, level int) *Stats {
 			agility:    1,
 			confidence: 3,
 			luck:       0,
-		}, false)
+		}
 	case Ranger:
-		return NewStats(&Stats{
+		return &Stats{
 			level:      1,
 			totalHp:    5,
 			strength:   2,
@@ -197,10 +323,53 @@ func getStartingStats(kind ProfessionKind, level int) *Stats {
 			agility:    3,
 			confidence: 0,
 			luck:       0,
-		}, false)
+		}
+	case Paladin:
+		return &Stats{
+			level:      level,
+			totalHp:    9,
+			strength:   3,
+			wisdom:     2,
+			defense:    4,
+			agility:    1,
+			confidence: 6,
+			luck:       0,
+		}
+	case Bishop:
+		return &Stats{
+			level:      level,
+			totalHp:    6,
+			strength:   1,
+			wisdom:     5,
+			defense:    2,
+			agility:    2,
+			confidence: 1,
+			luck:       1,
+		}
+	case Swashbuckler:
+		return &Stats{
+			level:      level,
+			totalHp:    7,
+			strength:   4,
+			wisdom:     1,
+			defense:    2,
+			agility:    2,
+			confidence: 3,
+			luck:       2,
+		}
+	case Sharpshooter:
+		return &Stats{
+			level:      level,
+			totalHp:    6,
+			strength:   4,
+			wisdom:     1,
+			defense:    1,
+			agility:    4,
+			confidence: 1,
+			luck:       1,
+		}
 	default:
 		// you useless jobless bum
-		return NewStats(nil, false)
-
+		return nil
 	}
 }
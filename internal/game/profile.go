@@ -0,0 +1,324 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	ErrProfileUnavailable = Error("profile storage unavailable")
+	ErrUnlockMaxRank      = Error("unlock is at max rank")
+	ErrNotEnoughLegacy    = Error("not enough legacy")
+)
+
+// UnlockKind is an enumeration of the permanent upgrades bought with legacy.
+type UnlockKind int
+
+const (
+	// UnlockStartingGold adds gold at the start of every run.
+	UnlockStartingGold UnlockKind = iota
+	// UnlockRoomKinds adds more room kinds to the early room pools.
+	UnlockRoomKinds
+	// UnlockStartingDudes adds more dudes at the start of every run.
+	UnlockStartingDudes
+	// UnlockRerollDiscount makes rerolling rooms cheaper.
+	UnlockRerollDiscount
+	// Marker for the end... allows for iteration
+	UnlockKindEnd
+)
+
+func (u UnlockKind) String() string {
+	switch u {
+	case UnlockStartingGold:
+		return "starting gold"
+	case UnlockRoomKinds:
+		return "room kinds"
+	case UnlockStartingDudes:
+		return "starting dudes"
+	case UnlockRerollDiscount:
+		return "reroll discount"
+	default:
+		return "unknown"
+	}
+}
+
+// Description describes what the next rank of the unlock does.
+func (u UnlockKind) Description() string {
+	switch u {
+	case UnlockStartingGold:
+		return "+250 gold at the start of every run"
+	case UnlockRoomKinds:
+		return "more room kinds offered on early stories"
+	case UnlockStartingDudes:
+		return "+1 dude at the start of every run"
+	case UnlockRerollDiscount:
+		return "rerolling rooms costs 10% less"
+	default:
+		return ""
+	}
+}
+
+// MaxRank returns the highest rank the unlock can be bought to.
+func (u UnlockKind) MaxRank() int {
+	switch u {
+	case UnlockRoomKinds:
+		return 2
+	case UnlockRerollDiscount:
+		return 5
+	default:
+		return 4
+	}
+}
+
+// Cost returns the legacy needed to buy the given rank of the unlock.
+func (u UnlockKind) Cost(rank int) int {
+	switch u {
+	case UnlockStartingDudes:
+		return 10 * rank
+	case UnlockRoomKinds:
+		return 8 * rank
+	default:
+		return 5 * rank
+	}
+}
+
+// HeroRecord is a dude remembered in the Hall of Heroes.
+type HeroRecord struct {
+	Name       string `json:"name"`
+	Profession string `json:"profession"`
+	Level      int    `json:"level"`
+	Stories    int    `json:"stories"`
+	Date       string `json:"date"`
+}
+
+// HeirloomPerk is a perk socketed into an heirloom.
+type HeirloomPerk struct {
+	Name    string `json:"name"`
+	Stat    string `json:"stat,omitempty"`
+	Quality int    `json:"quality"`
+}
+
+// Heirloom is an item kept from an escaped dude for the next run.
+type Heirloom struct {
+	Owner   string         `json:"owner"`
+	Name    string         `json:"name"`
+	Unique  string         `json:"unique,omitempty"`
+	Quality int            `json:"quality"`
+	Level   int            `json:"level"`
+	Perks   []HeirloomPerk `json:"perks,omitempty"`
+}
+
+// NewHeirloom records the equipment as an heirloom of the owner.
+func NewHeirloom(owner *Dude, e *Equipment) Heirloom {
+	h := Heirloom{
+		Owner:   owner.Name(),
+		Name:    e.baseName,
+		Unique:  e.uniqueName,
+		Quality: int(e.quality),
+		Level:   e.Level(),
+	}
+	for _, p := range e.Perks() {
+		h.Perks = append(h.Perks, HeirloomPerk{
+			Name:    p.String(),
+			Stat:    string(PerkStat(p)),
+			Quality: int(p.Quality()),
+		})
+	}
+	return h
+}
+
+// Equipment recreates the heirloom's equipment.
+func (h Heirloom) Equipment() *Equipment {
+	quality := EquipmentQuality(h.Quality)
+	e := NewEquipment(h.Name, 0, quality, nil)
+	if e == nil {
+		return nil
+	}
+	// Uniques level with their own stats.
+	if u := GetUniqueItem(h.Unique); u != nil {
+		u.Apply(e)
+	}
+	// Heirlooms keep their own perks rather than the base equipment's.
+	e.sockets = nil
+	for _, p := range h.Perks {
+		e.Socket(GetPerkByName(p.Name, Stat(p.Stat), PerkQuality(p.Quality)))
+	}
+	for e.Level() < h.Level {
+		if !e.LevelUp(quality) {
+			break
+		}
+	}
+	e.uniqueName = h.Unique
+	return e
+}
+
+// Profile is the meta-progression that persists between runs.
+type Profile struct {
+	Legacy    int            `json:"legacy"`
+	Unlocks   map[string]int `json:"unlocks"`
+	Heroes    []HeroRecord   `json:"heroes"`
+	Heirlooms []Heirloom     `json:"heirlooms"`
+	Loot      *LootPolicy    `json:"loot"`
+}
+
+var profile = NewProfile()
+
+// NewProfile creates an empty profile.
+func NewProfile() *Profile {
+	return &Profile{
+		Unlocks: make(map[string]int),
+		Loot:    NewLootPolicy(),
+	}
+}
+
+// GetProfile returns the current profile.
+func GetProfile() *Profile {
+	return profile
+}
+
+// profilePath returns where the profile is stored.
+func profilePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", errors.Join(ErrProfileUnavailable, err)
+	}
+	return filepath.Join(dir, "ebijam24", "profile.json"), nil
+}
+
+// LoadProfile loads the profile from disk. A missing profile is not an error.
+func LoadProfile() error {
+	path, err := profilePath()
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	p := NewProfile()
+	if err := json.Unmarshal(b, p); err != nil {
+		return err
+	}
+	if p.Unlocks == nil {
+		p.Unlocks = make(map[string]int)
+	}
+	if p.Loot == nil {
+		p.Loot = NewLootPolicy()
+	} else if p.Loot.PriorityStats == nil {
+		p.Loot.PriorityStats = make(map[string][]Stat)
+	}
+	profile = p
+	return nil
+}
+
+// Save writes the profile to disk.
+func (p *Profile) Save() error {
+	path, err := profilePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
+
+// SaveProfile saves the profile, reporting but otherwise ignoring failures as the game can go on without it.
+func SaveProfile() {
+	if err := profile.Save(); err != nil {
+		fmt.Println("Error saving profile", err)
+	}
+}
+
+// Rank returns the bought rank of the unlock.
+func (p *Profile) Rank(u UnlockKind) int {
+	return p.Unlocks[u.String()]
+}
+
+// CanBuy returns why the next rank of the unlock can't be bought, or nil if it can.
+func (p *Profile) CanBuy(u UnlockKind) error {
+	rank := p.Rank(u)
+	if rank >= u.MaxRank() {
+		return ErrUnlockMaxRank
+	}
+	if p.Legacy < u.Cost(rank+1) {
+		return ErrNotEnoughLegacy
+	}
+	return nil
+}
+
+// Buy spends legacy on the next rank of the unlock.
+func (p *Profile) Buy(u UnlockKind) error {
+	if err := p.CanBuy(u); err != nil {
+		return err
+	}
+	rank := p.Rank(u) + 1
+	p.Legacy -= u.Cost(rank)
+	p.Unlocks[u.String()] = rank
+	return nil
+}
+
+// AddLegacy awards legacy for clearing the given story.
+func (p *Profile) AddLegacy(storyLevel int) int {
+	amount := 1
+	// Boss stories are worth more.
+	if (storyLevel+1)%3 == 0 {
+		amount = 3
+	}
+	p.Legacy += amount
+	return amount
+}
+
+// AddHero records the dude in the Hall of Heroes.
+func (p *Profile) AddHero(d *Dude, stories int) {
+	profession := d.Profession()
+	p.Heroes = append(p.Heroes, HeroRecord{
+		Name:       d.Name(),
+		Profession: profession.String(),
+		Level:      d.Level(),
+		Stories:    stories,
+		Date:       time.Now().Format(time.DateOnly),
+	})
+}
+
+// KeepHeirloom keeps the dude's chosen equipped item for the next run.
+func (p *Profile) KeepHeirloom(d *Dude, eq *Equipment) {
+	p.Heirlooms = append(p.Heirlooms, NewHeirloom(d, eq))
+}
+
+// TakeHeirlooms returns the equipment of all kept heirlooms, clearing them from the profile.
+func (p *Profile) TakeHeirlooms() []*Equipment {
+	var items []*Equipment
+	for _, h := range p.Heirlooms {
+		if e := h.Equipment(); e != nil {
+			items = append(items, e)
+		}
+	}
+	p.Heirlooms = nil
+	return items
+}
+
+// StartingGold returns the gold a run starts with.
+func (p *Profile) StartingGold() int {
+	return 750 + 250*p.Rank(UnlockStartingGold)
+}
+
+// StartingDudes returns the number of extra dudes a run starts with.
+func (p *Profile) StartingDudes() int {
+	return p.Rank(UnlockStartingDudes)
+}
+
+// RerollDiscount returns the fraction taken off room rerolls.
+func (p *Profile) RerollDiscount() float64 {
+	return 0.1 * float64(p.Rank(UnlockRerollDiscount))
+}
@@ -0,0 +1,41 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+)
+
+// RevivalPenalty is the fraction of max hp a revived dude loses until it next levels up.
+const RevivalPenalty = 0.25
+
+// ReviveCost returns the gold needed to revive the dude, scaling with its level.
+func (d *Dude) ReviveCost() int {
+	return 50 + 75*d.Level()
+}
+
+// Revive brings a dead dude back with the given fraction of its health. Its max hp is reduced until it next levels up.
+func (d *Dude) Revive(hpFraction float64) {
+	if !d.IsDead() {
+		return
+	}
+	if d.room != nil {
+		d.room.RemoveCorpse(d)
+	}
+	d.revivalPenalty = int(float64(d.stats.totalHp) * RevivalPenalty)
+	stats := d.GetCalculatedStats()
+	d.stats.currentHp = max(1, int(float64(stats.totalHp)*hpFraction))
+	d.enemy = nil
+	d.SetActivity(BornAgain)
+	d.ResetMorale()
+	d.floatingText("*revived*", color.NRGBA{200, 150, 255, 255}, 80, 0.8)
+	AddMessage(
+		MessageGood,
+		fmt.Sprintf("%s has been revived!", d.name),
+	)
+	d.dirtyStats = true
+}
+
+// RevivalPenalty returns how much max hp the dude is missing from being revived.
+func (d *Dude) RevivalPenalty() int {
+	return d.revivalPenalty
+}
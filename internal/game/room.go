@@ -7,6 +7,7 @@ import (
 	"sort"
 	"strings"
 
+	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/kettek/ebijam24/internal/render"
 )
 
@@ -74,6 +75,8 @@ func (r *RoomKind) String() string {
 		return "template"
 	case Trap:
 		return "trap"
+	case ResurrectionShrine:
+		return "resurrection"
 	case Boss:
 		return "boss"
 	default:
@@ -81,6 +84,27 @@ func (r *RoomKind) String() string {
 	}
 }
 
+// Stack returns the name of the stack used to render the room kind.
+func (r *RoomKind) Stack() string {
+	switch *r {
+	case ResurrectionShrine:
+		// Re-use the healing shrine, but tinted.
+		return "healing"
+	default:
+		return r.String()
+	}
+}
+
+// Tint returns the color scale applied to the room kind's stack.
+func (r *RoomKind) Tint() ebiten.ColorScale {
+	cs := ebiten.ColorScale{}
+	switch *r {
+	case ResurrectionShrine:
+		cs.Scale(0.8, 0.7, 1.2, 1)
+	}
+	return cs
+}
+
 func (r *RoomKind) GetRoomEnemy(roomSize RoomSize, storyLevel int) EnemyKind {
 	switch *r {
 	case Combat:
@@ -149,6 +173,8 @@ const (
 	Curse
 	// Trap - % to damage dude based on stats
 	Trap
+	// ResurrectionShrine revives dudes that fell on the same story, for a price.
+	ResurrectionShrine
 
 	// Boss room
 	Boss
@@ -178,6 +204,7 @@ type Room struct {
 	dudesInCenter  []*Dude
 	dudesInWaiting []*Dude
 	dudes          []*Dude
+	corpses        []*Corpse
 	highlight      bool
 }
 
@@ -189,16 +216,17 @@ func NewRoom(size RoomSize, kind RoomKind, required bool) *Room {
 		killedBoss: false,
 	}
 
-	stack, err := render.NewStack(fmt.Sprintf("rooms/%s", size.String()), kind.String(), "")
+	stack, err := render.NewStack(fmt.Sprintf("rooms/%s", size.String()), kind.Stack(), "")
 	if err != nil {
 		panic(err)
 	}
+	stack.ColorScale = kind.Tint()
 	r.stacks.Add(stack)
 
 	// Add our walls.
 	for j := 0; j < 3; j++ {
 		for i := 0; i < 8; i++ {
-			stack, err := render.NewStack(fmt.Sprintf("walls/%s", size.String()), kind.String(), "base")
+			stack, err := render.NewStack(fmt.Sprintf("walls/%s", size.String()), kind.Stack(), "base")
 			if err != nil {
 				stack, err = render.NewStack(fmt.Sprintf("walls/%s", size.String()), "template", "base")
 				if err != nil {
@@ -223,6 +251,7 @@ func (r *Room) Update(req *ActivityRequests, g *Game) {
 		r.combatTicks++
 		if r.combatTicks >= CombatTickrate {
 			r.combatTicks = 0
+			r.Rally()
 			for _, d := range r.dudes {
 				req.Add(RoomCombatActivity{room: r, dude: d})
 			}
@@ -238,7 +267,7 @@ func (r *Room) Update(req *ActivityRequests, g *Game) {
 					}
 				}
 				goldPerDude := int(r.boss.Gold() / aliveDudes)
-				xp := r.boss.XP() * 5
+				xp := r.boss.XP()
 				AddMessage(
 					MessageGood,
 					fmt.Sprintf("The %s has been defeated!", r.boss.Name()),
@@ -247,27 +276,72 @@ func (r *Room) Update(req *ActivityRequests, g *Game) {
 					MessageLoot,
 					fmt.Sprintf("All dudes have earned %d XP and %d gold ", xp, goldPerDude),
 				)
-				r.boss = nil
-				r.killedBoss = true
+
+				// Roll the boss' drops with the luckiest dude in the room and hand them out.
+				var winners []*Dude
+				luck := 0
 				for _, d := range r.dudes {
 					if !d.IsDead() {
-						d.AddXP(xp)
-						d.UpdateGold(goldPerDude)
-						req.Add(RoomEndBossActivity{room: r, dude: d})
+						winners = append(winners, d)
+						luck = max(luck, d.GetCalculatedStats().luck)
 					}
 				}
+				if len(winners) > 0 {
+					for _, loot := range r.boss.RollLoot(luck, r.story.level) {
+						d := winners[rand.Intn(len(winners))]
+						d.AddToInventory(loot)
+						AddCombatMessage(
+							MessageLoot,
+							NewCombatEntry(d, CombatActionLoot, loot.Name(), 0),
+							fmt.Sprintf("%s looted %s from %s", d.name, loot.Name(), r.boss.Name()),
+						)
+					}
+					// Bosses may be guarding a unique.
+					if loot := RollUnique(r.story.level, true); loot != nil {
+						d := winners[rand.Intn(len(winners))]
+						d.AddToInventory(loot)
+						AddCombatMessage(
+							MessageLoot,
+							NewCombatEntry(d, CombatActionLoot, loot.Name(), 0),
+							fmt.Sprintf("%s looted %s from %s", d.name, loot.DisplayName(), r.boss.Name()),
+						)
+					}
+					// Bosses sometimes hand out a piece of a set one of the slayers is after.
+					if rand.Float64() < SetDropChance {
+						d := winners[rand.Intn(len(winners))]
+						if loot := d.RollSetPiece(luck, r.story.level); loot != nil {
+							d.AddToInventory(loot)
+							AddCombatMessage(
+								MessageLoot,
+								NewCombatEntry(d, CombatActionLoot, loot.Name(), 0),
+								fmt.Sprintf("%s looted %s, a set piece, from %s", d.name, loot.Name(), r.boss.Name()),
+							)
+						}
+					}
+				}
+
+				r.boss = nil
+				r.killedBoss = true
+				for _, d := range winners {
+					d.bossKills++
+					d.AddXP(xp)
+					d.UpdateGold(goldPerDude)
+					req.Add(RoomEndBossActivity{room: r, dude: d})
+				}
 			} else {
 				// Boss combat
 				r.combatTicks++
 				if r.combatTicks >= CombatTickrate {
 					r.combatTicks = 0
+					r.Rally()
 					bossTarget := r.boss.GetTarget(r.dudes)
 					if bossTarget != nil {
 						amount, dodged := bossTarget.ApplyDamage(r.boss.Hit())
-						act := bossTarget.Trigger(EventDudeHit{dude: bossTarget, amount: amount})
+						act := bossTarget.Trigger(EventDudeHit{dude: bossTarget, enemy: r.boss, room: r, amount: amount})
 						if !dodged && !bossTarget.IsDead() {
-							AddMessage(
+							AddCombatMessage(
 								MessageBad,
+								NewCombatEntryAgainst(bossTarget, CombatActionHit, r.boss.Name(), amount),
 								fmt.Sprintf("%s took %d damage from %s", bossTarget.name, amount, r.boss.Name()),
 							)
 							bossTarget.stats.ModifyStat(StatConfidence, -1)
@@ -278,13 +352,19 @@ func (r *Room) Update(req *ActivityRequests, g *Game) {
 					}
 					for _, d := range r.dudes {
 						if !d.IsDead() && !r.boss.IsDead() {
-							dmg, _ := d.GetDamage()
-							if dmg > 0 {
-								AddMessage(
+							dmg, isCrit := d.GetDamage()
+							if dmg > 0 && !r.boss.Evades(d) {
+								action := CombatActionHit
+								if isCrit {
+									action = CombatActionCrit
+								}
+								AddCombatMessage(
 									MessageNeutral,
+									NewCombatEntry(d, action, r.boss.Name(), dmg),
 									fmt.Sprintf("%s dealt %d damage to %s", d.Name(), dmg, r.boss.Name()),
 								)
 								isDead := r.boss.Damage(dmg)
+								d.Trigger(EventEnemyHit{dude: d, enemy: r.boss, amount: dmg})
 								if isDead {
 									break
 								}
@@ -294,24 +374,30 @@ func (r *Room) Update(req *ActivityRequests, g *Game) {
 				}
 			}
 		} else {
-			// If all dudes are waiting, trigger boss fight
-			aliveDudes := 0
-			for _, d := range g.dudes {
-				if !d.IsDead() {
-					aliveDudes++
+			// If all dudes of a party still in the tower are waiting, trigger boss fight
+			for party := range g.parties {
+				aliveDudes := 0
+				for _, d := range g.tower.dudes {
+					if !d.IsDead() && d.party == party {
+						aliveDudes++
+					}
 				}
-			}
-			if aliveDudes > 0 && r.AreAllDudesWaiting(aliveDudes) {
-				r.RemoveDudesFromWaiting()
-				for _, d := range r.dudes {
-					req.Add(RoomStartBossActivity{room: r, dude: d})
+				if aliveDudes == 0 || !r.AreAllDudesWaiting(party, aliveDudes) {
+					continue
+				}
+				for _, d := range r.dudesInWaiting {
+					if d.party == party {
+						req.Add(RoomStartBossActivity{room: r, dude: d})
+					}
 				}
+				r.RemoveDudesFromWaiting(party)
 				bossEnemy := r.kind.GetRoomEnemy(Huge, r.story.level)
 				bossStack, err := render.NewStack("enemies/"+r.size.String(), strings.ToLower(bossEnemy.BossStack()), "")
 				if err != nil {
 					fmt.Println("Error creating boss stack for", bossEnemy.String(), err)
 				}
 				r.boss = NewEnemy(bossEnemy, r.story.level, bossStack)
+				break
 			}
 		}
 	}
@@ -321,6 +407,7 @@ func (r *Room) Reset() {
 	r.dudes = nil
 	r.dudesInCenter = nil
 	r.dudesInWaiting = nil
+	r.corpses = nil
 	r.boss = nil
 	r.killedBoss = false
 	r.combatTicks = 0
@@ -374,6 +461,10 @@ func (r *Room) Draw(o *render.Options) {
 	r.walls.Draw(o)
 	o.DrawImageOptions.ColorScale.Reset()
 
+	for _, c := range r.corpses {
+		c.Draw(o)
+	}
+
 	if r.boss != nil {
 		r.boss.Draw(*o)
 	}
@@ -403,11 +494,22 @@ func (r *Room) IsDudeWaiting(a *Dude) bool {
 	}
 	return false
 }
-func (r *Room) AreAllDudesWaiting(n int) bool {
-	return len(r.dudesInWaiting) == n
+func (r *Room) AreAllDudesWaiting(party int, n int) bool {
+	waiting := 0
+	for _, dude := range r.dudesInWaiting {
+		if dude.party == party {
+			waiting++
+		}
+	}
+	return waiting == n
 }
-func (r *Room) RemoveDudesFromWaiting() {
-	r.dudesInWaiting = nil
+func (r *Room) RemoveDudesFromWaiting(party int) {
+	for i := 0; i < len(r.dudesInWaiting); i++ {
+		if r.dudesInWaiting[i].party == party {
+			r.dudesInWaiting = append(r.dudesInWaiting[:i], r.dudesInWaiting[i+1:]...)
+			i--
+		}
+	}
 }
 
 func (r *Room) AddDudeToCenter(a *Dude) {
@@ -448,46 +550,24 @@ func (r *Room) RollLoot(luck int) *Equipment {
 		return nil
 	}
 
-	// Determine the initial quality of the equipment based on luck
-	fromLuck := float64(luck) / 10.0
-	fromRoomLevel := float64(r.story.level) / 3.0
-	initialQuality := EquipmentQuality((math.Floor(fromLuck + fromRoomLevel)))
-	if initialQuality > EquipmentQualityLegendary {
-		initialQuality = EquipmentQualityLegendary
-	}
-
 	// Determine if perk exists based on luck
-	// Determine perk quality based on luck and room level
 	// Base chance is 5%
 	// Max chance is 25%
-	var perk IPerk = nil
-
-	hasPerk := rand.Float64() < math.Min(0.05+float64(luck)/100.0, 0.25)
-	if hasPerk {
-		fromLuck = float64(luck) / 10.0
-		fromRoomLevel = float64(r.story.level) / 3.0
-		perkQuality := PerkQuality((math.Floor(fromLuck + fromRoomLevel)))
-		if perkQuality > PerkQualityGodly {
-			perkQuality = PerkQualityGodly
-		}
+	perkChance := math.Min(0.05+float64(luck)/100.0, 0.25)
 
-		perk = GetRandomPerk(perkQuality)
+	// The deep stories hide uniques
+	if e := RollUnique(r.story.level, false); e != nil {
+		return e
 	}
 
-	// Create equipment
-	list := r.kind.Equipment()
-	equipmentName := list[rand.Intn(len(list))]
-	equipment := NewEquipment(*equipmentName, 1, initialQuality, perk)
-	if equipment == nil {
-		return nil
+	// Sometimes it's something to drink instead
+	if rand.Float64() < ConsumableLootChance {
+		return GetRandomConsumable()
 	}
 
-	// Level up the equipment based on floor level
-	for i := 0; i < r.story.level; i++ {
-		equipment.LevelUp(EquipmentQualityLegendary)
-	}
-
-	return equipment
+	// Create equipment
+	list := r.kind.Equipment()
+	return RollEquipment(*list[rand.Intn(len(list))], luck, r.story.level, EquipmentQualityCommon, perkChance)
 }
 
 func (r *Room) GetRoomEffect(e Event) Activity {
@@ -521,6 +601,7 @@ func (r *Room) GetRoomEffect(e Event) Activity {
 				fmt.Println("Error creating enemy stack", err)
 			} else {
 				enemy := NewEnemy(enemyName, r.story.level, enemyStack)
+				enemy.RollRank(r.story.level)
 				e.dude.enemy = enemy
 			}
 		}
@@ -590,8 +671,30 @@ func (r *Room) GetRoomEffect(e Event) Activity {
 			// Add gold
 			goldAmount := (r.story.level + 1) * rand.Intn(10*int(r.size))
 			e.dude.Trigger(EventGoldGain{dude: e.dude, amount: goldAmount})
+		case ResurrectionShrine:
+			// Revive a dude that fell on this story, if the visitor can pay for it
+			hpFraction := 0.25
+			if r.size == Medium {
+				hpFraction = 0.5
+			}
+			for _, fallen := range r.story.dudes {
+				if fallen == e.dude || !fallen.IsDead() {
+					continue
+				}
+				cost := fallen.ReviveCost()
+				if e.dude.gold < cost {
+					continue
+				}
+				e.dude.UpdateGold(-cost)
+				fallen.Revive(hpFraction)
+				AddMessage(
+					MessageGood,
+					fmt.Sprintf("%s paid %d gold to revive %s", e.dude.name, cost, fallen.name),
+				)
+				break
+			}
 		case Library:
-			// Level up a random equipment perk or add one
+			// Fill an empty perk socket on a random equipment, or level up its weakest perk
 			maxQuality := PerkQuality(r.story.level/2 + 1)
 			if maxQuality > PerkQualityGodly {
 				maxQuality = PerkQualityGodly
@@ -747,11 +850,13 @@ func GetOptionalRooms(storyLevel int, roomSpace int) []*RoomDef {
 			potentialRooms,
 			RoomTemplate{kind: HealingShrine, size: Medium},
 			RoomTemplate{kind: Treasure, size: Medium},
+			RoomTemplate{kind: ResurrectionShrine, size: Small},
 		)
 	}
 	if level > 6 {
 		potentialRooms = append(
 			potentialRooms,
+			RoomTemplate{kind: ResurrectionShrine, size: Medium},
 			RoomTemplate{kind: Armory, size: Large},
 			RoomTemplate{kind: HealingShrine, size: Large},
 			RoomTemplate{kind: Treasure, size: Large},
@@ -764,6 +869,21 @@ func GetOptionalRooms(storyLevel int, roomSpace int) []*RoomDef {
 		)
 	}
 
+	// Legacy unlocks offer rooms earlier than usual.
+	unlocked := profile.Rank(UnlockRoomKinds)
+	if unlocked >= 1 && level <= 3 {
+		potentialRooms = append(
+			potentialRooms,
+			RoomTemplate{kind: ResurrectionShrine, size: Small},
+		)
+	}
+	if unlocked >= 2 && level <= 6 {
+		potentialRooms = append(
+			potentialRooms,
+			RoomTemplate{kind: ResurrectionShrine, size: Medium},
+		)
+	}
+
 	rooms := make([]*RoomDef, 0)
 	attempts := 0
 	for i := 0; i < roomSpace; {
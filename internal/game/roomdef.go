@@ -24,10 +24,11 @@ func GetRoomDef(kind RoomKind, size RoomSize, required bool) *RoomDef {
 		return r
 	}
 
-	stack, err := render.NewStack(fmt.Sprintf("rooms/%s", size.String()), kind.String(), "")
+	stack, err := render.NewStack(fmt.Sprintf("rooms/%s", size.String()), kind.Stack(), "")
 	if err != nil {
 		panic(err)
 	}
+	stack.ColorScale = kind.Tint()
 	/*stack.SetOriginToCenter()
 	stack.SetRotation(math.Pi / 8)
 	stack.SetPosition(10, 4)*/
@@ -85,6 +86,13 @@ func (r *RoomDef) GetDescription() string {
 		case Large:
 			return "Heals 100% of your health"
 		}
+	case ResurrectionShrine:
+		switch r.size {
+		case Small:
+			return "Revives a dude that fell on this story at 25% health, for gold"
+		case Medium:
+			return "Revives a dude that fell on this story at 50% health, for gold"
+		}
 	case Combat:
 		return "Engage with enemies to gain gold and XP!"
 	case Well:
@@ -133,6 +141,13 @@ func GetRoomCost(kind RoomKind, size RoomSize, level int) int {
 		case Large:
 			cost = 500
 		}
+	case ResurrectionShrine:
+		switch size {
+		case Small:
+			cost = 300
+		case Medium:
+			cost = 600
+		}
 	case Well:
 		cost = 125
 	case Treasure:
@@ -0,0 +1,240 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+
+	"github.com/kettek/ebijam24/assets"
+)
+
+// SetDropChance is the chance a boss drops a set piece for one of its slayers.
+const SetDropChance = 0.35
+
+// EquipmentSetBonus is a bonus given once enough pieces of a set are equipped.
+type EquipmentSetBonus struct {
+	set    *EquipmentSet
+	pieces int
+	stats  *Stats
+	perk   IPerk // Granted perk, if any. Never runs out of uses.
+}
+
+// EquipmentSet is a group of equipment that gives bonuses when worn together.
+type EquipmentSet struct {
+	name        string
+	description string
+	members     []string // Base names of the equipment in the set.
+	bonuses     []EquipmentSetBonus
+}
+
+var equipmentSets []*EquipmentSet
+
+// LoadEquipmentSets loads the equipment sets from assets, must be called after the equipment is loaded.
+func LoadEquipmentSets() {
+	assets.LoadEquipmentSets()
+
+	equipmentSets = nil
+	for _, a := range assets.GetEquipmentSets() {
+		s := &EquipmentSet{
+			name:        a.Name,
+			description: a.Description,
+			members:     a.Members,
+		}
+		for _, b := range a.Bonuses {
+			bonus := EquipmentSetBonus{
+				set:    s,
+				pieces: b.Pieces,
+				stats: &Stats{
+					totalHp:    b.Stats["totalHp"],
+					strength:   b.Stats["strength"],
+					wisdom:     b.Stats["wisdom"],
+					defense:    b.Stats["defense"],
+					agility:    b.Stats["agility"],
+					confidence: b.Stats["confidence"],
+					luck:       b.Stats["luck"],
+				},
+			}
+			if b.Perk != "" {
+				bonus.perk = GetPerkByName(b.Perk, Stat(b.PerkStat), PerkQuality(b.PerkQuality))
+				if bonus.perk == nil {
+					fmt.Println("Unknown perk in equipment set", a.Name, b.Perk)
+				}
+			}
+			s.bonuses = append(s.bonuses, bonus)
+		}
+		equipmentSets = append(equipmentSets, s)
+	}
+}
+
+// Name returns the name of the set.
+func (s *EquipmentSet) Name() string {
+	return s.name
+}
+
+// Size returns how many pieces are in the set.
+func (s *EquipmentSet) Size() int {
+	return len(s.members)
+}
+
+// Has returns if the equipment is a piece of the set.
+func (s *EquipmentSet) Has(e *Equipment) bool {
+	if e == nil {
+		return false
+	}
+	for _, m := range s.members {
+		if m == e.baseName {
+			return true
+		}
+	}
+	return false
+}
+
+// Pieces returns how many different pieces of the set are in the equipped items.
+func (s *EquipmentSet) Pieces(equipped map[EquipmentSlot]*Equipment) int {
+	count := 0
+	for _, m := range s.members {
+		if wearing(equipped, m) {
+			count++
+		}
+	}
+	return count
+}
+
+// wearing returns if any of the equipped items has the base name.
+func wearing(equipped map[EquipmentSlot]*Equipment, baseName string) bool {
+	for _, e := range equipped {
+		if e != nil && e.baseName == baseName {
+			return true
+		}
+	}
+	return false
+}
+
+// Bonuses returns the bonuses active with the given number of pieces equipped.
+func (s *EquipmentSet) Bonuses(pieces int) []EquipmentSetBonus {
+	var bonuses []EquipmentSetBonus
+	for _, b := range s.bonuses {
+		if pieces >= b.pieces {
+			bonuses = append(bonuses, b)
+		}
+	}
+	return bonuses
+}
+
+// String describes the bonus, such as "2 pieces: +4 defense, Common Crit Heal".
+func (b EquipmentSetBonus) String() string {
+	var parts []string
+	stats := []struct {
+		amount int
+		name   string
+	}{
+		{b.stats.totalHp, "max hp"},
+		{b.stats.strength, "strength"},
+		{b.stats.wisdom, "wisdom"},
+		{b.stats.defense, "defense"},
+		{b.stats.agility, "agility"},
+		{b.stats.confidence, "confidence"},
+		{b.stats.luck, "luck"},
+	}
+	for _, s := range stats {
+		if s.amount != 0 {
+			parts = append(parts, fmt.Sprintf("%+d %s", s.amount, s.name))
+		}
+	}
+	if b.perk != nil {
+		parts = append(parts, b.perk.Name())
+	}
+	return fmt.Sprintf("%d pieces: %s", b.pieces, strings.Join(parts, ", "))
+}
+
+// Sets returns the sets the equipment is a piece of.
+func (e *Equipment) Sets() []*EquipmentSet {
+	var sets []*EquipmentSet
+	for _, s := range equipmentSets {
+		if s.Has(e) {
+			sets = append(sets, s)
+		}
+	}
+	return sets
+}
+
+// SetsDescription describes the sets the equipment is a piece of, counting the pieces the dude has equipped if given.
+func (e *Equipment) SetsDescription(d *Dude) string {
+	var lines []string
+	for _, s := range e.Sets() {
+		if d != nil {
+			lines = append(lines, fmt.Sprintf("%s set (%d/%d)", s.name, s.Pieces(d.equipped), s.Size()))
+		} else {
+			lines = append(lines, fmt.Sprintf("%s set (%d pieces)", s.name, s.Size()))
+		}
+		for _, b := range s.bonuses {
+			lines = append(lines, " "+b.String())
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// equipmentSetBonuses returns the set bonuses active for the equipped items.
+func equipmentSetBonuses(equipped map[EquipmentSlot]*Equipment) []EquipmentSetBonus {
+	var bonuses []EquipmentSetBonus
+	for _, s := range equipmentSets {
+		bonuses = append(bonuses, s.Bonuses(s.Pieces(equipped))...)
+	}
+	return bonuses
+}
+
+// SetBonuses returns the dude's active set bonuses.
+func (d *Dude) SetBonuses() []EquipmentSetBonus {
+	return equipmentSetBonuses(d.equipped)
+}
+
+// SetBonusesWith returns the set bonuses the dude would have with the equipment swapped in.
+func (d *Dude) SetBonusesWith(eq *Equipment) []EquipmentSetBonus {
+	return equipmentSetBonuses(d.EquippedWith(eq))
+}
+
+// SetStats returns the stats the dude gets from active set bonuses.
+func (d *Dude) SetStats() *Stats {
+	stats := &Stats{}
+	for _, b := range d.SetBonuses() {
+		stats = stats.Add(b.stats)
+	}
+	return stats
+}
+
+// TriggerSetPerks checks the perks granted by the dude's active set bonuses.
+func (d *Dude) TriggerSetPerks(e Event) {
+	for _, b := range d.SetBonuses() {
+		if b.perk == nil {
+			continue
+		}
+		combatLog.source = b.set.name
+		b.perk.Check(e)
+		combatLog.source = ""
+	}
+}
+
+// RollSetPiece rolls a piece of a set the dude has yet to complete, favoring sets the dude has started.
+func (d *Dude) RollSetPiece(luck int, storyLevel int) *Equipment {
+	var missing []string
+	best := -1
+	for _, s := range equipmentSets {
+		pieces := s.Pieces(d.equipped)
+		if pieces >= s.Size() || pieces < best {
+			continue
+		}
+		if pieces > best {
+			best = pieces
+			missing = nil
+		}
+		for _, m := range s.members {
+			if !wearing(d.equipped, m) {
+				missing = append(missing, m)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return RollEquipment(missing[rand.Intn(len(missing))], luck, storyLevel, EquipmentQualityUncommon, 0.25)
+}
@@ -0,0 +1,102 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const (
+	ShopStockSize = 5    // Items for sale each build phase.
+	ShopSetChance = 0.2  // Chance each item in stock is a piece of an equipment set.
+	ShopMarkup    = 2.5  // Price multiplier over what the item would sell for.
+	ShopPerkPrice = 40.0 // Price added per perk quality, for each socketed perk.
+)
+
+// ShopItem is an item for sale in the shop.
+type ShopItem struct {
+	equipment *Equipment
+	reserved  bool // Kept in stock across restocks.
+}
+
+// Price returns what the item costs, from its quality, level and perk.
+func (si *ShopItem) Price() int {
+	e := si.equipment
+	price := 25 + float64(e.GoldValue())*ShopMarkup
+	for _, p := range e.Perks() {
+		price += ShopPerkPrice * float64(p.Quality()+1)
+	}
+	return int(price)
+}
+
+// Description describes the item and its price for the shop listing.
+func (si *ShopItem) Description() string {
+	e := si.equipment
+	reserved := ""
+	if si.reserved {
+		reserved = " (reserved)"
+	}
+	if e.IsConsumable() {
+		return fmt.Sprintf("%s%s\n%s\nBuy for %dgp", e.Name(), reserved, e.consumable.Description(), si.Price())
+	}
+	perk := "no perk"
+	if e.HasPerks() {
+		perk = e.PerkNames()
+	}
+	return fmt.Sprintf("%s lvl %d%s\n%s, %s\nBuy for %dgp", e.Name(), e.Level(), reserved, e.SlotString(), perk, si.Price())
+}
+
+// ShopQuality returns the lowest quality stocked for the story level, rising every few stories.
+func ShopQuality(storyLevel int) EquipmentQuality {
+	return min(EquipmentQuality(storyLevel/3), EquipmentQualityEpic)
+}
+
+// RollShopEquipment rolls an item to stock for the story level.
+func RollShopEquipment(storyLevel int) *Equipment {
+	var e *Equipment
+	if rand.Float64() < ShopSetChance && len(equipmentSets) > 0 {
+		set := equipmentSets[rand.Intn(len(equipmentSets))]
+		e = RollEquipment(set.members[rand.Intn(len(set.members))], 0, storyLevel, EquipmentQualityCommon, 0.1)
+	} else {
+		e = GetRandomEquipment(storyLevel)
+	}
+	if e == nil {
+		return nil
+	}
+	for e.quality < ShopQuality(storyLevel) {
+		e.ChangeQuality(1)
+	}
+	return e
+}
+
+// RestockShop replaces the stock with fresh items, keeping the reserved one.
+func RestockShop(stock []*ShopItem, storyLevel int) []*ShopItem {
+	var restocked []*ShopItem
+	for _, si := range stock {
+		if si.reserved {
+			restocked = append(restocked, si)
+		}
+	}
+	for len(restocked) < ShopStockSize {
+		e := RollShopEquipment(storyLevel)
+		if e == nil {
+			break
+		}
+		restocked = append(restocked, &ShopItem{equipment: e})
+	}
+	for i := 0; i < ShopConsumableCount; i++ {
+		if e := GetRandomConsumable(); e != nil {
+			restocked = append(restocked, &ShopItem{equipment: e})
+		}
+	}
+	return restocked
+}
+
+// ToggleReserve reserves the item at the given index, releasing any other reservation. Returns if the item is now reserved.
+func ToggleReserve(stock []*ShopItem, index int) bool {
+	reserve := !stock[index].reserved
+	for _, si := range stock {
+		si.reserved = false
+	}
+	stock[index].reserved = reserve
+	return reserve
+}
@@ -0,0 +1,237 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// SkillKind is an enumeration of the skills dudes can learn.
+type SkillKind int
+
+const (
+	// Knight
+	SkillToughness SkillKind = iota
+	SkillShieldWall
+	SkillValor
+	// Cleric
+	SkillDevotion
+	SkillSanctuary
+	SkillMending
+	// Vagabond
+	SkillScrounger
+	SkillPickpocket
+	SkillDirtyFighting
+	// Ranger
+	SkillKeenEye
+	SkillVolley
+	SkillFleetFoot
+	// Marker for the end... allows for iteration
+	SkillKindEnd
+)
+
+const (
+	ErrSkillWrongProfession = Error("skill belongs to another profession")
+	ErrSkillMaxRank         = Error("skill is at max rank")
+	ErrSkillLevelTooLow     = Error("level too low for skill")
+	ErrSkillMissingRequired = Error("required skill not learned")
+	ErrSkillNoPoints        = Error("no skill points")
+)
+
+func (s SkillKind) String() string {
+	switch s {
+	case SkillToughness:
+		return "Toughness"
+	case SkillShieldWall:
+		return "Shield Wall"
+	case SkillValor:
+		return "Valor"
+	case SkillDevotion:
+		return "Devotion"
+	case SkillSanctuary:
+		return "Sanctuary"
+	case SkillMending:
+		return "Mending"
+	case SkillScrounger:
+		return "Scrounger"
+	case SkillPickpocket:
+		return "Pickpocket"
+	case SkillDirtyFighting:
+		return "Dirty Fighting"
+	case SkillKeenEye:
+		return "Keen Eye"
+	case SkillVolley:
+		return "Volley"
+	case SkillFleetFoot:
+		return "Fleet Foot"
+	default:
+		return "Unknown"
+	}
+}
+
+// Description describes what the skill does at the given rank.
+func (s SkillKind) Description(rank int) string {
+	rank = max(1, rank)
+	switch s {
+	case SkillToughness:
+		return fmt.Sprintf("+%d max hp, +%d defense", rank*10, rank)
+	case SkillShieldWall:
+		return fmt.Sprintf("%d%% chance to recover half the damage taken", rank*15)
+	case SkillValor:
+		return fmt.Sprintf("+%d confidence, +%d strength", rank*3, rank)
+	case SkillDevotion:
+		return fmt.Sprintf("+%d wisdom", rank*2)
+	case SkillSanctuary:
+		return fmt.Sprintf("heals dudes in a room for %d when reaching its center", rank*5)
+	case SkillMending:
+		return fmt.Sprintf("heals self for %d when entering a room", rank*3)
+	case SkillScrounger:
+		return fmt.Sprintf("+%d luck", rank*2)
+	case SkillPickpocket:
+		return fmt.Sprintf("%d%% chance to steal gold in combat", rank*10)
+	case SkillDirtyFighting:
+		return fmt.Sprintf("crits deal %d extra damage", rank*5)
+	case SkillKeenEye:
+		return fmt.Sprintf("+%d luck, +%d strength", rank, rank)
+	case SkillVolley:
+		return fmt.Sprintf("%d%% chance for an extra attack in combat", rank*15)
+	case SkillFleetFoot:
+		return fmt.Sprintf("+%d agility", rank*2)
+	default:
+		return ""
+	}
+}
+
+// Profession returns the profession that can learn the skill.
+func (s SkillKind) Profession() ProfessionKind {
+	switch s {
+	case SkillToughness, SkillShieldWall, SkillValor:
+		return Knight
+	case SkillDevotion, SkillSanctuary, SkillMending:
+		return Cleric
+	case SkillScrounger, SkillPickpocket, SkillDirtyFighting:
+		return Vagabond
+	case SkillKeenEye, SkillVolley, SkillFleetFoot:
+		return Ranger
+	default:
+		return ""
+	}
+}
+
+// MaxRank returns the highest rank the skill can be learned to.
+func (s SkillKind) MaxRank() int {
+	return 3
+}
+
+// RequiredLevel returns the dude level needed to learn the skill.
+func (s SkillKind) RequiredLevel() int {
+	switch s {
+	case SkillShieldWall, SkillSanctuary, SkillPickpocket, SkillVolley:
+		return 5
+	case SkillValor, SkillMending, SkillDirtyFighting, SkillFleetFoot:
+		return 10
+	default:
+		return 1
+	}
+}
+
+// Requires returns the skill that must be learned first, if any.
+func (s SkillKind) Requires() (SkillKind, bool) {
+	switch s {
+	case SkillShieldWall, SkillValor:
+		return SkillToughness, true
+	case SkillSanctuary, SkillMending:
+		return SkillDevotion, true
+	case SkillPickpocket, SkillDirtyFighting:
+		return SkillScrounger, true
+	case SkillVolley, SkillFleetFoot:
+		return SkillKeenEye, true
+	default:
+		return 0, false
+	}
+}
+
+// Stats returns the passive stats granted by the skill at the given rank.
+func (s SkillKind) Stats(rank int) *Stats {
+	switch s {
+	case SkillToughness:
+		return &Stats{totalHp: rank * 10, defense: rank}
+	case SkillValor:
+		return &Stats{confidence: rank * 3, strength: rank}
+	case SkillDevotion:
+		return &Stats{wisdom: rank * 2}
+	case SkillScrounger:
+		return &Stats{luck: rank * 2}
+	case SkillKeenEye:
+		return &Stats{luck: rank, strength: rank}
+	case SkillFleetFoot:
+		return &Stats{agility: rank * 2}
+	default:
+		return nil
+	}
+}
+
+// Check runs the skill's triggered effect for the event, returns true if it activated.
+func (s SkillKind) Check(d *Dude, rank int, e Event) bool {
+	switch s {
+	case SkillShieldWall:
+		if e, ok := e.(EventDudeHit); ok && e.amount > 0 && !d.IsDead() {
+			if rand.Float64() < float64(rank)*0.15 {
+				d.Heal(e.amount / 2)
+				return true
+			}
+		}
+	case SkillSanctuary:
+		if e, ok := e.(EventCenterRoom); ok && e.room != nil {
+			for _, other := range e.room.dudes {
+				healed := other.Heal(rank * 5)
+				// Healing others counts towards the cleric's promotion too.
+				if other != d {
+					d.healingDone += healed
+				}
+			}
+			return true
+		}
+	case SkillMending:
+		if _, ok := e.(EventEnterRoom); ok {
+			d.Heal(rank * 3)
+			return true
+		}
+	case SkillPickpocket:
+		if _, ok := e.(EventCombatRoom); ok && d.enemy != nil && d.story != nil {
+			if rand.Float64() < float64(rank)*0.1 {
+				d.Trigger(EventGoldGain{dude: d, amount: rank * 5 * (d.story.level + 1)})
+				return true
+			}
+		}
+	case SkillDirtyFighting:
+		if e, ok := e.(EventDudeCrit); ok && e.enemy != nil && e.enemy == d.enemy && !e.enemy.Evades(d) {
+			d.DamageEnemy(rank * 5)
+			return true
+		}
+	case SkillVolley:
+		if _, ok := e.(EventCombatRoom); ok && d.enemy != nil {
+			if rand.Float64() < float64(rank)*0.15 && !d.enemy.Evades(d) {
+				damage := d.GetCalculatedStats().strength
+				AddCombatMessage(
+					MessageNeutral,
+					NewCombatEntry(d, CombatActionHit, d.enemy.Name(), damage),
+					fmt.Sprintf("%s loosed a volley at %s", d.name, d.enemy.Name()),
+				)
+				d.DamageEnemy(damage)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GetProfessionSkills returns the skill tree of the profession, in learning order.
+func GetProfessionSkills(pk ProfessionKind) []SkillKind {
+	var skills []SkillKind
+	for s := SkillKind(0); s < SkillKindEnd; s++ {
+		if s.Profession() == pk {
+			skills = append(skills, s)
+		}
+	}
+	return skills
+}
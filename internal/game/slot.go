@@ -0,0 +1,183 @@
+package game
+
+import (
+	"image/color"
+	"math/rand"
+
+	"github.com/kettek/ebijam24/assets"
+)
+
+// EquipmentSlot is a place on a dude that equipment can be worn.
+type EquipmentSlot int
+
+const (
+	EquipmentSlotHead EquipmentSlot = iota
+	EquipmentSlotBody
+	EquipmentSlotMainHand
+	EquipmentSlotOffHand
+	EquipmentSlotAccessory1
+	EquipmentSlotAccessory2
+	// Marker for the end... allows for iteration
+	EquipmentSlotEnd
+)
+
+func (s EquipmentSlot) String() string {
+	switch s {
+	case EquipmentSlotHead:
+		return "Head"
+	case EquipmentSlotBody:
+		return "Body"
+	case EquipmentSlotMainHand:
+		return "Main Hand"
+	case EquipmentSlotOffHand:
+		return "Off Hand"
+	case EquipmentSlotAccessory1, EquipmentSlotAccessory2:
+		return "Accessory"
+	default:
+		return "Unknown"
+	}
+}
+
+// Empty returns what to show for the slot when nothing is worn in it.
+func (s EquipmentSlot) Empty() string {
+	switch s {
+	case EquipmentSlotHead:
+		return "no helm"
+	case EquipmentSlotBody:
+		return "no armor"
+	case EquipmentSlotMainHand:
+		return "no weapon"
+	case EquipmentSlotOffHand:
+		return "empty hand"
+	default:
+		return "no accessory"
+	}
+}
+
+// Color returns the color the slot is shown with when empty.
+func (s EquipmentSlot) Color() color.Color {
+	switch s {
+	case EquipmentSlotHead:
+		return assets.ColorDudeConfidence
+	case EquipmentSlotBody:
+		return assets.ColorDudeDefense
+	case EquipmentSlotMainHand:
+		return assets.ColorDudeStrength
+	case EquipmentSlotOffHand:
+		return assets.ColorDudeAgility
+	default:
+		return assets.ColorDudeWisdom
+	}
+}
+
+// RandomEquipmentSlot returns a random slot.
+func RandomEquipmentSlot() EquipmentSlot {
+	return EquipmentSlot(rand.Intn(int(EquipmentSlotEnd)))
+}
+
+// EquipmentSlots returns the slots for a slot name from equipment yaml, falling back to the usual slots of the equipment type.
+func EquipmentSlots(name string, t EquipmentType, twoHanded bool) []EquipmentSlot {
+	if twoHanded {
+		return []EquipmentSlot{EquipmentSlotMainHand}
+	}
+	switch name {
+	case "head":
+		return []EquipmentSlot{EquipmentSlotHead}
+	case "body":
+		return []EquipmentSlot{EquipmentSlotBody}
+	case "mainhand":
+		return []EquipmentSlot{EquipmentSlotMainHand}
+	case "offhand":
+		return []EquipmentSlot{EquipmentSlotOffHand}
+	case "hand":
+		return []EquipmentSlot{EquipmentSlotMainHand, EquipmentSlotOffHand}
+	case "accessory":
+		return []EquipmentSlot{EquipmentSlotAccessory1, EquipmentSlotAccessory2}
+	}
+	switch t {
+	case EquipmentTypeWeapon:
+		return []EquipmentSlot{EquipmentSlotMainHand}
+	case EquipmentTypeArmor:
+		return []EquipmentSlot{EquipmentSlotBody}
+	case EquipmentTypeConsumable:
+		return nil
+	default:
+		return []EquipmentSlot{EquipmentSlotAccessory1, EquipmentSlotAccessory2}
+	}
+}
+
+// Slots returns the slots the equipment can be worn in.
+func (e *Equipment) Slots() []EquipmentSlot {
+	return e.slots
+}
+
+// TwoHanded returns if the equipment takes up both hands.
+func (e *Equipment) TwoHanded() bool {
+	return e.twoHanded
+}
+
+// SlotString describes where the equipment is worn.
+func (e *Equipment) SlotString() string {
+	if e.twoHanded {
+		return "Two-Handed"
+	}
+	if len(e.slots) == 2 && e.slots[0] == EquipmentSlotMainHand && e.slots[1] == EquipmentSlotOffHand {
+		return "One-Handed"
+	}
+	if len(e.slots) > 0 {
+		return e.slots[0].String()
+	}
+	return e.Type().String()
+}
+
+// SlotFor returns the slot the equipment would be worn in: the first free slot it fits, otherwise the one holding the worst item. Consumables aren't worn, so get EquipmentSlotEnd.
+func (d *Dude) SlotFor(eq *Equipment) EquipmentSlot {
+	slots := eq.Slots()
+	if len(slots) == 0 {
+		return EquipmentSlotEnd
+	}
+	for _, s := range slots {
+		if d.equipped[s] == nil && !(s == EquipmentSlotOffHand && d.OffHandBlocked()) {
+			return s
+		}
+	}
+	best := slots[0]
+	for _, s := range slots[1:] {
+		if worn, current := d.equipped[s], d.equipped[best]; worn != nil && current != nil && worn.LevelWithQuality() < current.LevelWithQuality() {
+			best = s
+		}
+	}
+	return best
+}
+
+// OffHandBlocked returns if a two-handed weapon is taking up the off hand.
+func (d *Dude) OffHandBlocked() bool {
+	main := d.equipped[EquipmentSlotMainHand]
+	return main != nil && main.twoHanded
+}
+
+// Displaced returns the items that wearing the equipment in the slot would take off.
+func (d *Dude) Displaced(eq *Equipment, slot EquipmentSlot) []*Equipment {
+	var displaced []*Equipment
+	if worn := d.equipped[slot]; worn != nil && worn != eq {
+		displaced = append(displaced, worn)
+	}
+	if eq.twoHanded {
+		if off := d.equipped[EquipmentSlotOffHand]; off != nil && off != eq {
+			displaced = append(displaced, off)
+		}
+	} else if slot == EquipmentSlotOffHand && d.OffHandBlocked() {
+		displaced = append(displaced, d.equipped[EquipmentSlotMainHand])
+	}
+	return displaced
+}
+
+// SlotOf returns the slot the equipment is worn in, if the dude is wearing it.
+func (d *Dude) SlotOf(eq *Equipment) (EquipmentSlot, bool) {
+	for s, worn := range d.equipped {
+		if worn == eq {
+			return s, true
+		}
+	}
+	return EquipmentSlotEnd, false
+}
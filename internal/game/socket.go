@@ -0,0 +1,111 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PerkSocket is a perk socketed into equipment, with its own uses.
+type PerkSocket struct {
+	perk      IPerk
+	uses      int // Current uses of the perk
+	totalUses int // Total uses of the perk
+}
+
+// Perk returns the socketed perk.
+func (ps *PerkSocket) Perk() IPerk {
+	return ps.perk
+}
+
+// UsesString describes the socket's uses, such as "2/3 uses".
+func (ps *PerkSocket) UsesString() string {
+	return fmt.Sprintf("%d/%d uses", ps.uses, ps.totalUses)
+}
+
+// Sockets returns how many perks equipment of the quality can hold.
+func (eq EquipmentQuality) Sockets() int {
+	switch eq {
+	case EquipmentQualityCommon, EquipmentQualityUncommon:
+		return 1
+	case EquipmentQualityRare, EquipmentQualityEpic:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// Sockets returns the equipment's socketed perks.
+func (e *Equipment) Sockets() []*PerkSocket {
+	return e.sockets
+}
+
+// Perks returns the equipment's perks.
+func (e *Equipment) Perks() []IPerk {
+	var perks []IPerk
+	for _, s := range e.sockets {
+		perks = append(perks, s.perk)
+	}
+	return perks
+}
+
+// HasPerks returns if the equipment has any perks socketed.
+func (e *Equipment) HasPerks() bool {
+	return len(e.sockets) > 0
+}
+
+// FreeSockets returns how many more perks the equipment can hold. Losing quality keeps perks already socketed.
+func (e *Equipment) FreeSockets() int {
+	return max(e.quality.Sockets()-len(e.sockets), 0)
+}
+
+// Socket adds the perk to a free socket, returning false if there is none.
+func (e *Equipment) Socket(p IPerk) bool {
+	if p == nil || e.FreeSockets() == 0 {
+		return false
+	}
+	e.sockets = append(e.sockets, &PerkSocket{
+		perk:      p,
+		uses:      int(e.quality) + 1,
+		totalUses: int(e.quality) + 1,
+	})
+	return true
+}
+
+// PerkNames returns the names of the equipment's perks joined together, or an empty string if it has none.
+func (e *Equipment) PerkNames() string {
+	var names []string
+	for _, p := range e.Perks() {
+		names = append(names, strings.TrimSpace(p.Name()))
+	}
+	return strings.Join(names, ", ")
+}
+
+// PerkScore weighs the equipment's perks by their quality, for sorting and pricing.
+func (e *Equipment) PerkScore() int {
+	score := 0
+	for _, p := range e.Perks() {
+		score += int(p.Quality()) + 1
+	}
+	return score
+}
+
+// HasPerkLike returns if the equipment has a perk of the same kind, and stat for stat boosts, at the given quality or better.
+func (e *Equipment) HasPerkLike(p IPerk) bool {
+	for _, own := range e.Perks() {
+		if own.String() == p.String() && PerkStat(own) == PerkStat(p) && own.Quality() >= p.Quality() {
+			return true
+		}
+	}
+	return false
+}
+
+// LowestPerk returns the socket holding the lowest quality perk, or nil if there are none.
+func (e *Equipment) LowestPerk() *PerkSocket {
+	var lowest *PerkSocket
+	for _, s := range e.sockets {
+		if lowest == nil || s.perk.Quality() < lowest.perk.Quality() {
+			lowest = s
+		}
+	}
+	return lowest
+}
@@ -0,0 +1,124 @@
+package game
+
+import (
+	"math"
+)
+
+const (
+	SteeringArriveAngle    = 0.001 // How close in angle a dude has to be to reach a waypoint.
+	SteeringArriveDistance = 0.05  // How close in distance a dude has to be to reach a waypoint.
+	SteeringRadialScale    = 100   // Dudes move in and out this many times faster than around.
+	SteeringBaseTurnRate   = 0.1   // Radians a dude can turn per tick before agility.
+	StairsClimbSpeed       = 0.005 // How fast dudes walk on and off the stairs.
+	PortalWalkSpeed        = 0.01  // How fast dudes walk into a portal.
+)
+
+// Waypoint is a spot a dude steers towards, as an angle and distance from the center of the tower.
+type Waypoint struct {
+	angle    float64
+	distance float64
+}
+
+// Steering walks a dude through its queued waypoints.
+type Steering struct {
+	waypoints []Waypoint
+}
+
+// Queue adds waypoints to the end of the queue.
+func (s *Steering) Queue(w ...Waypoint) {
+	s.waypoints = append(s.waypoints, w...)
+}
+
+// Clear drops all queued waypoints.
+func (s *Steering) Clear() {
+	s.waypoints = nil
+}
+
+// Done returns if there are no waypoints left.
+func (s *Steering) Done() bool {
+	return len(s.waypoints) == 0
+}
+
+// Step returns where to move this tick to head towards the waypoints, moving at most speed around and speed*SteeringRadialScale in or out. Reached waypoints are dropped, and false is returned once none are left.
+func (s *Steering) Step(story *Story, x, y, speed float64) (float64, float64, bool) {
+	angle := story.AngleFromCenter(x, y)
+	distance := story.DistanceFromCenter(x, y)
+
+	for len(s.waypoints) > 0 {
+		w := s.waypoints[0]
+		if math.Abs(normalizeAngle(w.angle-angle)) <= SteeringArriveAngle && math.Abs(w.distance-distance) <= SteeringArriveDistance {
+			s.waypoints = s.waypoints[1:]
+			continue
+		}
+		da := clamp(normalizeAngle(w.angle-angle), -speed, speed)
+		dd := clamp(w.distance-distance, -speed*SteeringRadialScale, speed*SteeringRadialScale)
+		nx, ny := story.PositionFromCenter(angle+da, distance+dd)
+		return nx, ny, true
+	}
+	return x, y, false
+}
+
+// TurnRate returns how far the dude can turn in a tick, fast dudes turn faster.
+func (d *Dude) TurnRate() float64 {
+	return SteeringBaseTurnRate + d.Speed()*5
+}
+
+// Steer moves the dude a tick towards its waypoints at the given speed, turning smoothly towards where it is going. Returns false once there are no waypoints left.
+func (d *Dude) Steer(story *Story, speed float64, req *ActivityRequests) bool {
+	cx, cy := d.Position()
+	nx, ny, ok := d.steering.Step(story, cx, cy, speed)
+	if !ok {
+		return false
+	}
+
+	face := d.Rotation()
+	if math.Abs(nx-cx) > 1e-9 || math.Abs(ny-cy) > 1e-9 {
+		face = math.Atan2(ny-cy, nx-cx)
+	}
+	d.trueRotation = face
+
+	// Face inwards if we have an enemy!
+	if d.enemy != nil {
+		fx, fy := story.PositionFromCenter(story.AngleFromCenter(nx, ny), d.variation)
+		face = math.Atan2(fy-cy, fx-cx)
+	}
+	face = d.Rotation() + clamp(normalizeAngle(face-d.Rotation()), -d.TurnRate(), d.TurnRate())
+
+	req.Add(MoveActivity{dude: d, face: face, x: nx, y: ny, cb: func(success bool) {
+		if success {
+			d.SyncEquipment()
+		}
+	}})
+	return true
+}
+
+// Angle returns the dude's angle from the center of the tower.
+func (d *Dude) Angle(story *Story) float64 {
+	return story.AngleFromCenter(d.Position())
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(v, hi))
+}
+
+// FollowPath walks the dude counter-clockwise along the story's path, from node to node.
+func (d *Dude) FollowPath(story *Story, speed float64, req *ActivityRequests) {
+	if d.Steer(story, speed, req) {
+		return
+	}
+	if next := story.Paths().Ahead(d.Angle(story)); next != nil {
+		d.steering.Queue(next.Waypoint(d.variation))
+		d.Steer(story, speed, req)
+	}
+}
+
+// Retrace walks the dude clockwise back along the story's path, from node to node.
+func (d *Dude) Retrace(story *Story, speed float64, req *ActivityRequests) {
+	if d.Steer(story, speed, req) {
+		return
+	}
+	if prev := story.Paths().Behind(d.Angle(story)); prev != nil {
+		d.steering.Queue(prev.Waypoint(d.variation))
+		d.Steer(story, speed, req)
+	}
+}
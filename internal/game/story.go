@@ -1,6 +1,7 @@
 package game
 
 import (
+	"fmt"
 	"math"
 
 	"github.com/kettek/ebijam24/internal/render"
@@ -23,6 +24,9 @@ type Story struct {
 	hasRenderedWalls bool
 	hasClouds        bool
 	isLast           bool
+	cleared          bool       // Set once a dude makes it through the story.
+	legacyPaid       bool       // Set once clearing the story has earned legacy.
+	paths            *PathGraph // Rebuilt whenever the rooms or portal change.
 }
 
 // StoryHeight is the height of a story in da tower.
@@ -149,6 +153,16 @@ func (s *Story) Update(req *ActivityRequests, g *Game) {
 		case MoveActivity:
 			roomIndex := s.RoomIndexFromAngle(s.AngleFromCenter(u.x, u.y))
 			room := s.rooms[roomIndex]
+			// Scared dudes won't go near a living boss.
+			if room != nil && room != u.dude.room && room.kind == Boss && !room.killedBoss && u.dude.activity == Moving && u.dude.RefusesBoss() {
+				AddMessage(
+					MessageBad,
+					fmt.Sprintf("%s refused to face the boss!", u.dude.name),
+				)
+				u.dude.SetActivity(Leaving)
+				success = false
+				break
+			}
 			if room != u.dude.room {
 				// Add dude to the given room and remove from existing room.
 				if u.dude.room != nil {
@@ -164,7 +178,7 @@ func (s *Story) Update(req *ActivityRequests, g *Game) {
 				// Special case for boss room
 				if room.kind == Boss && !room.killedBoss {
 					// If dude is in first fourth of boss room, add it to the waiting list
-					if s.IsInCenterOfRoom(s.AngleFromCenter(u.x, u.y)-math.Pi/4, roomIndex) {
+					if u.dude.activity != Leaving && s.IsInCenterOfRoom(s.AngleFromCenter(u.x, u.y)-math.Pi/4, roomIndex) {
 						if !room.IsDudeWaiting(u.dude) {
 							room.AddDudeToWaiting(u.dude)
 							req.Add(RoomWaitActivity{dude: u.dude, room: room})
@@ -188,6 +202,9 @@ func (s *Story) Update(req *ActivityRequests, g *Game) {
 		case TowerLeaveActivity:
 			// Pass it up.
 			req.Add(u)
+		case TowerFleeActivity:
+			// Pass it up.
+			req.Add(u)
 		case DudeDeadActivity:
 			// Pass it up.
 			req.Add(u)
@@ -352,30 +369,43 @@ func (s *Story) RemoveDoor() {
 	s.doorStack = nil
 }
 
-func (s *Story) AddPortal() {
-	stack := Must(render.NewStack("walls/portal", "", ""))
-
-	r := PortalRotationMedium
+// PortalRotation returns the angle of the portal, which depends on the size of the last room.
+func (s *Story) PortalRotation() float64 {
 	switch s.rooms[6].size {
 	case Small:
-		r = PortalRotationSmall
+		return PortalRotationSmall
 	case Large:
-		r = PortalRotationLarge
+		return PortalRotationLarge
 	case Huge:
-		r = PortalRotationHuge
+		return PortalRotationHuge
 	}
+	return PortalRotationMedium
+}
+
+func (s *Story) AddPortal() {
+	stack := Must(render.NewStack("walls/portal", "", ""))
 
-	x, y := s.PositionFromCenter(r, PortalDistance)
+	x, y := s.PositionFromCenter(s.PortalRotation(), PortalDistance)
 
 	stack.SetRotation(math.Pi + math.Pi/2 - math.Pi/8)
 	stack.SetPosition(x, y)
 	stack.NoLighting = true
 	stack.VgroupOffset = 2
 	s.portalStack = stack
+	s.paths = nil
 }
 
 func (s *Story) RemovePortal() {
 	s.portalStack = nil
+	s.paths = nil
+}
+
+// Paths returns the path graph dudes follow around the story.
+func (s *Story) Paths() *PathGraph {
+	if s.paths == nil {
+		s.paths = NewPathGraph(s)
+	}
+	return s.paths
 }
 
 func (s *Story) Reset() {
@@ -427,6 +457,7 @@ func (s *Story) PlaceRoom(r *Room, index int) error {
 	}
 	r.story = s
 	r.index = index
+	s.paths = nil
 	return nil
 }
 
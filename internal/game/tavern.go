@@ -0,0 +1,107 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+)
+
+const (
+	TavernMinCandidates = 3
+	TavernMaxCandidates = 5
+)
+
+// Candidate is a dude waiting in the tavern to be hired.
+type Candidate struct {
+	dude    *Dude
+	quality EquipmentQuality // quality of the candidate's starting gear
+	premium float64          // multiplier on the base hiring cost
+}
+
+// NewCandidate rolls a candidate around the given average level, profession weighted by the current dudes.
+func NewCandidate(level int, dudes []*Dude) *Candidate {
+	candidateLevel := max(1, level+rand.Intn(3)-1)
+	d := NewDude(WeightedRandomProfessionKind(dudes), candidateLevel)
+
+	// Now and then a candidate shows up with better gear.
+	quality := EquipmentQualityCommon
+	for quality < EquipmentQualityRare && rand.Float64() < 0.25 {
+		quality++
+	}
+	for _, eq := range d.equipped {
+		for q := eq.quality; q < quality; q++ {
+			eq.ChangeQuality(1)
+		}
+	}
+	d.dirtyStats = true
+	d.dirtyEquipment = true
+
+	return &Candidate{
+		dude:    d,
+		quality: quality,
+		premium: math.Max(0.5, 1+0.25*float64(candidateLevel-level)) * (1 + 0.3*float64(quality)),
+	}
+}
+
+// RollCandidates rolls a fresh set of tavern candidates for the given dudes.
+func RollCandidates(dudes []*Dude) []*Candidate {
+	count := TavernMinCandidates + rand.Intn(TavernMaxCandidates-TavernMinCandidates+1)
+	level := AverageDudeLevel(dudes)
+	candidates := make([]*Candidate, 0, count)
+	for i := 0; i < count; i++ {
+		candidates = append(candidates, NewCandidate(level, dudes))
+	}
+	return candidates
+}
+
+// AverageDudeLevel returns the average level of the dudes, or 1 if there are none.
+func AverageDudeLevel(dudes []*Dude) int {
+	if len(dudes) == 0 {
+		return 1
+	}
+	level := 0
+	for _, d := range dudes {
+		level += d.stats.level
+	}
+	return max(1, level/len(dudes))
+}
+
+// Dude returns the candidate's dude.
+func (c *Candidate) Dude() *Dude {
+	return c.dude
+}
+
+// Cost returns what hiring the candidate costs given the base hiring cost.
+func (c *Candidate) Cost(baseCost int) int {
+	return int(float64(baseCost) * c.premium)
+}
+
+// Description describes the candidate's profession, stats, traits and gear.
+func (c *Candidate) Description() string {
+	d := c.dude
+	profession := d.Profession()
+	stats := d.GetCalculatedStats()
+
+	var gear []string
+	for s := EquipmentSlotHead; s < EquipmentSlotEnd; s++ {
+		if eq, ok := d.equipped[s]; ok && eq != nil {
+			gear = append(gear, eq.Name())
+		}
+	}
+	if len(gear) == 0 {
+		gear = append(gear, "no gear")
+	}
+	traits := TraitsString(d.Traits())
+	if traits == "" {
+		traits = "no traits"
+	}
+
+	return fmt.Sprintf(
+		"%s, level %d %s\n%d hp %d str %d wis %d def %d agi %d con %d luc\n%s\n%s",
+		d.Name(), d.Level(), profession.String(),
+		stats.totalHp, stats.strength, stats.wisdom, stats.defense, stats.agility, stats.confidence, stats.luck,
+		traits,
+		strings.Join(gear, ", "),
+	)
+}
@@ -24,6 +24,8 @@ func NewTower() *Tower {
 
 // Update the tower.
 func (t *Tower) Update(req *ActivityRequests, g *Game) {
+	TickCombatLog()
+
 	// TODO: Should this only update "active" stories?
 	var storyUpdates ActivityRequests
 	for _, s := range t.Stories {
@@ -32,9 +34,20 @@ func (t *Tower) Update(req *ActivityRequests, g *Game) {
 	for _, u := range storyUpdates {
 		switch u := u.(type) {
 		case DudeDeadActivity:
+			// Leave the body behind so others can loot it.
+			if u.dude.room != nil {
+				u.dude.room.AddCorpse(u.dude)
+			}
+			// Dudes on the same story are shaken by the death.
+			for _, d := range t.dudes {
+				if d.story != nil && d.story == u.dude.story {
+					d.WitnessDeath(u.dude)
+				}
+			}
 			// Propagate the dead dude up to the game.
 			req.Add(u)
 		case TowerLeaveActivity:
+			u.dude.story.cleared = true
 			nextStory := u.dude.story.level + 1
 			t.RemoveDude(u.dude)
 			if nextStory == t.targetStories {
@@ -44,8 +57,13 @@ func (t *Tower) Update(req *ActivityRequests, g *Game) {
 				req.Add(u)
 			}
 
+		case TowerFleeActivity:
+			t.RemoveDude(u.dude)
+			// Send it up to the game so it can see if all dudes are gone.
+			req.Add(u)
 		case StoryEnterNextActivity:
 			// We can always allow this to happen since the logic is triggered in RoomEnterActivity with index 7.
+			u.story.cleared = true
 			nextStory := t.Stories[u.story.level+1]
 
 			if u.dude.room != nil {
@@ -71,8 +89,13 @@ func (t *Tower) Update(req *ActivityRequests, g *Game) {
 				req.Add(act)
 			}
 			// If it's the last room, then move upwards and go poof (unless we're coming from stairs or are entering the tower for the first time).
-			if u.dude.activity != StairsFromDown && u.dude.activity != FirstEntering && u.room.index == 7 {
-				u.dude.SetActivity(StairsToUp)
+			if u.room.index == 7 {
+				if u.dude.activity == Leaving {
+					// Fleeing dudes head back down the stairs.
+					u.dude.SetActivity(GoingDown)
+				} else if u.dude.activity != StairsFromDown && u.dude.activity != FirstEntering {
+					u.dude.SetActivity(StairsToUp)
+				}
 			}
 		case RoomCenterActivity:
 			if act := u.dude.Trigger(EventCenterRoom{room: u.room, dude: u.dude}); act != nil {
@@ -208,6 +231,17 @@ func (t *Tower) AddDudes(dudes ...*Dude) {
 	}
 }
 
+// OpenStories returns how many stories of the tower are open.
+func (t *Tower) OpenStories() int {
+	count := 0
+	for _, s := range t.Stories {
+		if s.open {
+			count++
+		}
+	}
+	return count
+}
+
 func (t *Tower) HasAliveDudes() bool {
 	for _, d := range t.dudes {
 		if !d.IsDead() {
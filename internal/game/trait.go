@@ -0,0 +1,162 @@
+package game
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// TraitKind is an enumeration of the quirks a dude can be born with.
+type TraitKind int
+
+const (
+	// Greedy gains more gold, but curses are more likely to rob them.
+	TraitGreedy TraitKind = iota
+	// Cowardly dodges more often but has little confidence.
+	TraitCowardly
+	// GlassCannon hits hard but falls over easily.
+	TraitGlassCannon
+	// LuckyCharm is just lucky.
+	TraitLuckyCharm
+	// Stalwart is hard to hurt but slow.
+	TraitStalwart
+	// Nimble is quick on their feet.
+	TraitNimble
+	// Hardy has more hp and recovers more from healing.
+	TraitHardy
+	// Marker for the end... allows for iteration
+	TraitKindEnd
+)
+
+func (t TraitKind) String() string {
+	switch t {
+	case TraitGreedy:
+		return "Greedy"
+	case TraitCowardly:
+		return "Cowardly"
+	case TraitGlassCannon:
+		return "Glass Cannon"
+	case TraitLuckyCharm:
+		return "Lucky Charm"
+	case TraitStalwart:
+		return "Stalwart"
+	case TraitNimble:
+		return "Nimble"
+	case TraitHardy:
+		return "Hardy"
+	default:
+		return "Unknown"
+	}
+}
+
+// Description describes what the trait does.
+func (t TraitKind) Description() string {
+	switch t {
+	case TraitGreedy:
+		return "+25% gold gained, more likely to be robbed by curses"
+	case TraitCowardly:
+		return "+10% dodge chance, -5 confidence"
+	case TraitGlassCannon:
+		return "+5 strength, -15 max hp, -2 defense"
+	case TraitLuckyCharm:
+		return "+5 luck"
+	case TraitStalwart:
+		return "+3 defense, -1 agility"
+	case TraitNimble:
+		return "+4 agility"
+	case TraitHardy:
+		return "+15 max hp, heals for 2 more"
+	default:
+		return ""
+	}
+}
+
+// Stats returns the passive stats of the trait.
+func (t TraitKind) Stats() *Stats {
+	switch t {
+	case TraitCowardly:
+		return &Stats{confidence: -5}
+	case TraitGlassCannon:
+		return &Stats{strength: 5, totalHp: -15, defense: -2}
+	case TraitLuckyCharm:
+		return &Stats{luck: 5}
+	case TraitStalwart:
+		return &Stats{defense: 3, agility: -1}
+	case TraitNimble:
+		return &Stats{agility: 4}
+	case TraitHardy:
+		return &Stats{totalHp: 15}
+	default:
+		return nil
+	}
+}
+
+// DodgeBonus returns the extra dodge chance granted by the trait.
+func (t TraitKind) DodgeBonus() float64 {
+	if t == TraitCowardly {
+		return 0.1
+	}
+	return 0
+}
+
+// RobberyMultiplier returns how much more likely the trait makes a curse rob the dude of gold.
+func (t TraitKind) RobberyMultiplier() float64 {
+	if t == TraitGreedy {
+		return 1.25
+	}
+	return 1
+}
+
+// Conflicts returns if the trait cannot be rolled alongside the other.
+func (t TraitKind) Conflicts(other TraitKind) bool {
+	if t == other {
+		return true
+	}
+	pair := func(a, b TraitKind) bool {
+		return (t == a && other == b) || (t == b && other == a)
+	}
+	return pair(TraitCowardly, TraitStalwart) || pair(TraitGlassCannon, TraitHardy) || pair(TraitStalwart, TraitNimble)
+}
+
+// Check runs the trait's triggered effect for the event, returns true if it activated.
+func (t TraitKind) Check(d *Dude, e Event) bool {
+	switch t {
+	case TraitGreedy:
+		if e, ok := e.(EventGoldGain); ok && e.amount >= 4 {
+			d.RefundGold(e.amount / 4)
+			return true
+		}
+	}
+	return false
+}
+
+// RollTraits rolls one to three non-conflicting traits.
+func RollTraits() []TraitKind {
+	count := 1 + rand.Intn(3)
+	var traits []TraitKind
+	for _, i := range rand.Perm(int(TraitKindEnd)) {
+		if len(traits) >= count {
+			break
+		}
+		t := TraitKind(i)
+		conflicts := false
+		for _, other := range traits {
+			if t.Conflicts(other) {
+				conflicts = true
+				break
+			}
+		}
+		if !conflicts {
+			traits = append(traits, t)
+		}
+	}
+	return traits
+}
+
+// TraitsString joins the traits into a readable list.
+func TraitsString(traits []TraitKind) string {
+	names := make([]string, len(traits))
+	for i, t := range traits {
+		names[i] = t.String()
+	}
+	return strings.Join(names, ", ")
+}
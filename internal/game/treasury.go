@@ -0,0 +1,213 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strings"
+)
+
+const (
+	TaxRateDefault = 1.0
+	TaxRateStep    = 0.1
+	TaxRateNeutral = 0.5 // Taxes above this upset dudes, below this cheers them up.
+)
+
+// TaxMood returns the confidence modifier dudes get from the given tax rate.
+func TaxMood(rate float64) int {
+	return int(math.Round((TaxRateNeutral - rate) * 10))
+}
+
+// LedgerKind is where gold in the treasury came from or went to.
+type LedgerKind int
+
+const (
+	LedgerTaxes LedgerKind = iota
+	LedgerSales
+	LedgerBounties
+	LedgerRecovered // gold recovered from unlooted corpses
+	LedgerRooms
+	LedgerHires
+	LedgerRerolls
+	LedgerLoot
+	LedgerServices // promotions and revivals
+	LedgerForging  // perk rerolls
+	// Marker for the end... allows for iteration
+	LedgerKindEnd
+)
+
+func (l LedgerKind) String() string {
+	switch l {
+	case LedgerTaxes:
+		return "taxes"
+	case LedgerSales:
+		return "sales"
+	case LedgerBounties:
+		return "bounties"
+	case LedgerRecovered:
+		return "recovered"
+	case LedgerRooms:
+		return "rooms"
+	case LedgerHires:
+		return "hires"
+	case LedgerRerolls:
+		return "rerolls"
+	case LedgerLoot:
+		return "loot"
+	case LedgerServices:
+		return "services"
+	case LedgerForging:
+		return "forging"
+	default:
+		return "unknown"
+	}
+}
+
+// IsIncome returns if the ledger kind brings gold in rather than spending it.
+func (l LedgerKind) IsIncome() bool {
+	return l <= LedgerRecovered
+}
+
+// Treasury keeps a ledger of gold earned and spent per story.
+type Treasury struct {
+	stories []map[LedgerKind]int
+}
+
+// NewTreasury creates an empty treasury.
+func NewTreasury() *Treasury {
+	return &Treasury{}
+}
+
+// Record adds the amount to the story's ledger.
+func (t *Treasury) Record(story int, kind LedgerKind, amount int) {
+	if story < 0 {
+		story = 0
+	}
+	for len(t.stories) <= story {
+		t.stories = append(t.stories, make(map[LedgerKind]int))
+	}
+	t.stories[story][kind] += amount
+}
+
+// Net returns the income minus the spending of the story.
+func (t *Treasury) Net(story int) int {
+	if story < 0 || story >= len(t.stories) {
+		return 0
+	}
+	net := 0
+	for kind, amount := range t.stories[story] {
+		if kind.IsIncome() {
+			net += amount
+		} else {
+			net -= amount
+		}
+	}
+	return net
+}
+
+// Summary describes the income and spending of the most recent stories.
+func (t *Treasury) Summary(count int) string {
+	if len(t.stories) == 0 {
+		return "no gold has changed hands yet"
+	}
+	var lines []string
+	for story := len(t.stories) - 1; story >= 0 && story >= len(t.stories)-count; story-- {
+		var income, spending []string
+		for kind := LedgerKind(0); kind < LedgerKindEnd; kind++ {
+			amount, ok := t.stories[story][kind]
+			if !ok {
+				continue
+			}
+			entry := fmt.Sprintf("%s %d", kind, amount)
+			if kind.IsIncome() {
+				income = append(income, entry)
+			} else {
+				spending = append(spending, entry)
+			}
+		}
+		if len(income) == 0 {
+			income = append(income, "none")
+		}
+		if len(spending) == 0 {
+			spending = append(spending, "none")
+		}
+		lines = append(lines,
+			fmt.Sprintf("Story %d (net %d)", story+1, t.Net(story)),
+			"  in: "+strings.Join(income, ", "),
+			"  out: "+strings.Join(spending, ", "),
+		)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// LedgerStory returns the story the treasury is currently recording for.
+func (g *Game) LedgerStory() int {
+	if g.tower == nil {
+		return 0
+	}
+	return len(g.tower.Stories) - 1
+}
+
+// Earn adds gold to the treasury, recording where it came from.
+func (g *Game) Earn(kind LedgerKind, amount int) {
+	g.gold += amount
+	g.treasury.Record(g.LedgerStory(), kind, amount)
+	g.ui.treasuryPanel.SetLedger(g.treasury.Summary(3))
+}
+
+// Spend takes gold from the treasury, recording where it went. Refunds are negative spending.
+func (g *Game) Spend(kind LedgerKind, amount int) {
+	g.gold -= amount
+	g.treasury.Record(g.LedgerStory(), kind, amount)
+	g.ui.treasuryPanel.SetLedger(g.treasury.Summary(3))
+}
+
+// SetTaxRate changes the tax rate, clamped between 0 and 1.
+func (g *Game) SetTaxRate(rate float64) {
+	g.taxRate = math.Max(0, math.Min(math.Round(rate*10)/10, 1))
+	g.ui.treasuryPanel.SetTaxRate(g.taxRate)
+}
+
+// SetTaxMood sets how the dude feels about the tax rate.
+func (d *Dude) SetTaxMood(rate float64) {
+	d.taxMood = TaxMood(rate)
+	d.dirtyStats = true
+}
+
+// Tax takes the rate's share of the gold the dude earned this run, returning it. Gold kept from earlier runs is left alone.
+func (d *Dude) Tax(rate float64) int {
+	tax := int(math.Round(float64(min(d.runGold, d.gold)) * rate))
+	d.gold -= tax
+	d.runGold = 0
+	return tax
+}
+
+// HealingCost returns what a healing draught costs the dude.
+func (d *Dude) HealingCost() int {
+	return 10 + 5*d.Level()
+}
+
+// SpendOnHealing has a badly hurt dude pay for healing out of their own pocket, returns if they did.
+func (d *Dude) SpendOnHealing() bool {
+	if d.IsDead() {
+		return false
+	}
+	stats := d.GetCalculatedStats()
+	cost := d.HealingCost()
+	if d.stats.currentHp*2 >= stats.totalHp || d.gold < cost {
+		return false
+	}
+	d.UpdateGold(-cost)
+	d.floatingText(fmt.Sprintf("-%dgp", cost), color.NRGBA{255, 255, 0, 200}, 60, 0.5)
+	d.Heal(stats.totalHp / 3)
+	AddMessage(
+		MessageNeutral,
+		fmt.Sprintf("%s paid %d gold for a healing draught", d.name, cost),
+	)
+	return true
+}
+
+// BossBounty returns the bounty paid to the treasury for slaying the boss of the given story.
+func BossBounty(storyLevel int) int {
+	return 100 + 50*storyLevel
+}
@@ -5,6 +5,8 @@ import (
 	"image/color"
 	"math"
 	"math/rand"
+	"slices"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/inpututil"
@@ -36,6 +38,12 @@ type UI struct {
 	gameInfoPanel  GameInfoPanel
 	dudePanel      DudePanel
 	dudeInfoPanel  *DudeInfoPanel
+	tavernPanel    TavernPanel
+	shopPanel      *ShopPanel
+	treasuryPanel  *TreasuryPanel
+	partyPanel     *PartyPanel
+	forgePanel     *ForgePanel
+	lootRulesPanel *LootRulesPanel
 	equipmentPanel EquipmentPanel
 	speedPanel     SpeedPanel
 	messagePanel   MessagePanel
@@ -69,6 +77,12 @@ func NewUI() *UI {
 	ui.speedPanel = MakeSpeedPanel()
 	ui.dudePanel = MakeDudePanel()
 	ui.dudeInfoPanel = NewDudeInfoPanel()
+	ui.tavernPanel = MakeTavernPanel()
+	ui.shopPanel = NewShopPanel()
+	ui.treasuryPanel = NewTreasuryPanel()
+	ui.partyPanel = NewPartyPanel()
+	ui.forgePanel = NewForgePanel()
+	ui.lootRulesPanel = NewLootRulesPanel()
 	ui.equipmentPanel = MakeEquipmentPanel()
 	ui.roomPanel = MakeRoomPanel()
 	ui.roomInfoPanel = MakeRoomInfoPanel()
@@ -127,6 +141,37 @@ func (ui *UI) Layout(o *UIOptions) {
 
 	ui.gameInfoPanel.Layout(o)
 
+	// Treasury hangs off the right of the info.
+	ui.treasuryPanel.toggleButton.SetPosition(
+		ui.gameInfoPanel.panel.X()+ui.gameInfoPanel.panel.Width()+4*o.Scale,
+		4,
+	)
+	ui.treasuryPanel.Layout(o)
+
+	// Forge hangs off the right of the treasury.
+	ui.forgePanel.toggleButton.Layout(nil, o)
+	ui.forgePanel.toggleButton.SetPosition(
+		ui.treasuryPanel.toggleButton.X()+ui.treasuryPanel.toggleButton.Width()+4*o.Scale,
+		4,
+	)
+	ui.forgePanel.Layout(o)
+
+	// Loot rules hang off the right of the forge.
+	ui.lootRulesPanel.toggleButton.Layout(nil, o)
+	ui.lootRulesPanel.toggleButton.SetPosition(
+		ui.forgePanel.toggleButton.X()+ui.forgePanel.toggleButton.Width()+4*o.Scale,
+		4,
+	)
+	ui.lootRulesPanel.Layout(o)
+
+	// Parties hang off the left of the info.
+	ui.partyPanel.toggleButton.Layout(nil, o)
+	ui.partyPanel.toggleButton.SetPosition(
+		ui.gameInfoPanel.panel.X()-ui.partyPanel.toggleButton.Width()-4*o.Scale,
+		4,
+	)
+	ui.partyPanel.Layout(o)
+
 	// Hint Panel
 	ui.hint.panel.SetSize(
 		96*o.Scale,
@@ -169,7 +214,7 @@ func (ui *UI) Layout(o *UIOptions) {
 		64*o.Scale,
 		h*o.Scale,
 	)
-	ts := ui.dudeInfoPanel.title.Width() + 8*o.Scale
+	ts := max(ui.dudeInfoPanel.title.Width(), ui.dudeInfoPanel.traits.Width()) + 8*o.Scale
 	if ts > ui.dudeInfoPanel.panel.Width() {
 		ts = math.Ceil(ts/ui.dudeInfoPanel.panel.center.Width()) * ui.dudeInfoPanel.panel.center.Width()
 		ui.dudeInfoPanel.panel.SetSize(ts, ui.dudeInfoPanel.panel.Height())
@@ -181,6 +226,9 @@ func (ui *UI) Layout(o *UIOptions) {
 
 	ui.equipmentPanel.Layout(o)
 
+	ui.tavernPanel.Layout(o)
+	ui.shopPanel.Layout(o)
+
 	// Manually position roomPanel
 	ui.roomPanel.panel.SetSize(
 		96*o.Scale,
@@ -243,6 +291,12 @@ func (ui *UI) Update(o *UIOptions) {
 
 	ui.dudePanel.Update(o)
 	ui.dudeInfoPanel.Update(o)
+	ui.tavernPanel.Update(o)
+	ui.shopPanel.Update(o)
+	ui.treasuryPanel.Update(o)
+	ui.partyPanel.Update(o)
+	ui.forgePanel.Update(o)
+	ui.lootRulesPanel.Update(o)
 	ui.equipmentPanel.Update(o)
 	ui.roomPanel.Update(o)
 	ui.speedPanel.Update(o)
@@ -259,6 +313,25 @@ func (ui *UI) Check(mx, my float64, kind UICheckKind) bool {
 		return false
 	}
 
+	// The tavern and shop sit on top of everything else while open.
+	if ui.tavernPanel.Check(mx, my, kind) {
+		return true
+	}
+	if ui.shopPanel.Check(mx, my, kind) {
+		return true
+	}
+	if ui.forgePanel.Check(mx, my, kind) {
+		return true
+	}
+	if ui.lootRulesPanel.Check(mx, my, kind) {
+		return true
+	}
+	if ui.treasuryPanel.Check(mx, my, kind) {
+		return true
+	}
+	if ui.partyPanel.Check(mx, my, kind) {
+		return true
+	}
 	if ui.dudePanel.Check(mx, my, kind) {
 		return true
 	}
@@ -283,6 +356,10 @@ func (ui *UI) Check(mx, my float64, kind UICheckKind) bool {
 	if ui.controlsPanel.Check(mx, my, kind) {
 		return true
 	}
+
+	if ui.messagePanel.Check(mx, my, kind) {
+		return true
+	}
 	return false
 }
 
@@ -323,6 +400,14 @@ func (ui *UI) Draw(o *render.Options) {
 
 	ui.bossPanel.Draw(o)
 
+	o.DrawImageOptions.GeoM.Reset()
+	ui.treasuryPanel.Draw(o)
+	ui.partyPanel.Draw(o)
+	ui.forgePanel.Draw(o)
+	ui.lootRulesPanel.Draw(o)
+	ui.tavernPanel.Draw(o)
+	ui.shopPanel.Draw(o)
+
 	ui.feedback.Draw(o)
 	o.DrawImageOptions.GeoM.Reset()
 	ui.hint.Draw(o)
@@ -493,6 +578,11 @@ type MessagePanel struct {
 	drawered bool
 	pinned   bool
 	maxLines int
+	filter   MessageFilter
+	dude     *Dude // Dude used by MessageFilterDude.
+	scroll   int   // How many messages back from the newest we are.
+	//
+	onExportClick func()
 	//drawerInterp render.InterpNumber
 	top      *render.Sprite
 	topleft  *render.Sprite
@@ -537,8 +627,32 @@ func (mp *MessagePanel) Update(o *UIOptions) {
 
 	//_, ph := mp.topleft.Size()
 
+	inBounds := mx > rpx && mx < maxX && my > rpy && my < maxY
+
+	// Scroll through the history with the wheel.
+	if inBounds {
+		if _, wy := ebiten.Wheel(); wy > 0 {
+			mp.scroll++
+		} else if wy < 0 {
+			mp.scroll--
+		}
+		if mp.scroll < 0 {
+			mp.scroll = 0
+		}
+	}
+
 	if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
-		if mx > rpx && mx < maxX && my > rpy && my < maxY {
+		if inBounds && mp.pinned && my < rpy+mp.headerHeight() {
+			// Header clicks either export or cycle the filter.
+			if mx > maxX-mp.width/4 {
+				if mp.onExportClick != nil {
+					mp.onExportClick()
+				}
+			} else {
+				mp.filter = mp.filter.Next()
+				mp.scroll = 0
+			}
+		} else if inBounds {
 			mp.pinned = !mp.pinned
 			/*if mp.drawered {
 				mp.drawered = false
@@ -553,6 +667,16 @@ func (mp *MessagePanel) Update(o *UIOptions) {
 	}
 }
 
+// Check only absorbs input so clicks on the log don't fall through to the tower, the actual handling is done in Update.
+func (mp *MessagePanel) Check(mx, my float64, kind UICheckKind) bool {
+	x, y := mp.Position()
+	return InBounds(x, y, mp.width, mp.height, mx, my)
+}
+
+func (mp *MessagePanel) headerHeight() float64 {
+	return assets.BodyFont.LineHeight + 10
+}
+
 func (mp *MessagePanel) Draw(o *render.Options) {
 	// Draw the panel
 	x, y := mp.Position()
@@ -587,7 +711,7 @@ func (mp *MessagePanel) Draw(o *render.Options) {
 		op.DrawImageOptions.GeoM.Translate(-mp.width+pw, ph)
 	}
 
-	messages := GetMessages()
+	messages := GetFilteredMessages(mp.filter, mp.dude)
 
 	// Set initial position to bottom right of message panel
 	//baseX := x + mp.width - 10
@@ -597,6 +721,28 @@ func (mp *MessagePanel) Draw(o *render.Options) {
 	lines := mp.maxLines
 	if mp.pinned {
 		lines *= 3
+
+		// Draw the filter and export header.
+		tOp := &render.TextOptions{
+			Screen:     o.Screen,
+			Font:       assets.BodyFont,
+			Color:      assets.ColorHeading,
+			ColorScale: o.DrawImageOptions.ColorScale,
+		}
+		tOp.GeoM.Translate(baseX, y+6)
+		header := fmt.Sprintf("filter: %s", mp.filter)
+		if mp.scroll > 0 {
+			header += fmt.Sprintf(" (-%d)", mp.scroll)
+		}
+		render.DrawText(tOp, header)
+		tOp.GeoM.Translate(mp.width-mp.width/4, 0)
+		render.DrawText(tOp, "export")
+		lines--
+	}
+
+	// Keep the scroll within the history
+	if mp.scroll > len(messages)-(lines-1) {
+		mp.scroll = max(0, len(messages)-(lines-1))
 	}
 
 	// Calculate the number of messages to display
@@ -604,7 +750,7 @@ func (mp *MessagePanel) Draw(o *render.Options) {
 
 	// Render messages from bottom to top
 	for i := 0; i < maxLines; i++ {
-		messageIndex := len(messages) - 1 - i
+		messageIndex := len(messages) - 1 - i - mp.scroll
 		if messageIndex < 0 {
 			break
 		}
@@ -832,18 +978,20 @@ func (gip *GameInfoPanel) Draw(o *render.Options) {
 }
 
 type DudePanel struct {
-	panel       *UIPanel
-	list        *UIItemList
-	count       *UIText
-	dudeSprites []*UIImage
-	dudes       []*Dude
-	title       *UIText
-	buyButton   *ButtonPanel
-	fillButton  *ButtonPanel
-	onBuyClick  func()
-	onFillClick func()
-	onItemClick func(index int)
-	onItemHover func(index int)
+	panel         *UIPanel
+	list          *UIItemList
+	count         *UIText
+	dudeSprites   []*UIImage
+	dudes         []*Dude
+	title         *UIText
+	buyButton     *ButtonPanel
+	fillButton    *ButtonPanel
+	reviveButton  *ButtonPanel
+	onBuyClick    func()
+	onFillClick   func()
+	onReviveClick func()
+	onItemClick   func(index int)
+	onItemHover   func(index int)
 }
 
 func MakeDudePanel() DudePanel {
@@ -857,13 +1005,19 @@ func MakeDudePanel() DudePanel {
 	buy := MakeButtonPanel(assets.BodyFont, PanelStyleButtonAttached)
 	dp.buyButton = &buy
 	dp.buyButton.text.center = true
-	dp.buyButton.text.SetText("Hire Dude")
+	dp.buyButton.text.SetText("Tavern")
 
 	fill := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
 	dp.fillButton = &fill
 	dp.fillButton.text.center = true
 	dp.fillButton.text.SetText("Fill")
 
+	revive := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	dp.reviveButton = &revive
+	dp.reviveButton.text.center = true
+	dp.reviveButton.text.SetText("Revive")
+	dp.reviveButton.hidden = true
+
 	dp.panel.AddChild(dp.title)
 	dp.panel.AddChild(dp.list)
 	dp.panel.sizeChildren = true
@@ -928,6 +1082,9 @@ func (dp *DudePanel) DudeToImage(dude *Dude) *UIImage {
 	stack.Draw(&profileOptions)
 
 	for _, eq := range dude.equipped {
+		if eq.stack == nil {
+			continue
+		}
 		estack := render.CopyStack(eq.stack)
 		estack.SetOrigin(stack.Origin())
 		estack.SetPosition(stack.Position())
@@ -942,6 +1099,9 @@ func (dp *DudePanel) DudeToImage(dude *Dude) *UIImage {
 		estack.Draw(&equipOptions)
 	}
 
+	// Tag the dude with its party's color.
+	vector.DrawFilledRect(img, 0, 0, 4, 4, PartyColors[dude.Party()], false)
+
 	return NewUIImage(profileOptions.Screen)
 }
 
@@ -968,6 +1128,12 @@ func (dp *DudePanel) Layout(o *UIOptions) {
 	dp.fillButton.text.SetPosition(dp.fillButton.text.X(), dp.fillButton.text.Y()+4*o.Scale)
 	// Next to buy button
 	dp.fillButton.SetPosition(dp.buyButton.X()+dp.buyButton.Width()+4*o.Scale, dp.panel.Y()+dp.panel.Height()-5*o.Scale)
+
+	dp.reviveButton.SetSize(dp.panel.Width(), 48)
+	dp.reviveButton.Layout(nil, o)
+	dp.reviveButton.text.SetPosition(dp.reviveButton.text.X(), dp.reviveButton.text.Y()+4*o.Scale)
+	// Other side of the buy button
+	dp.reviveButton.SetPosition(dp.buyButton.X()-dp.reviveButton.Width()-4*o.Scale, dp.panel.Y()+dp.panel.Height()-5*o.Scale)
 }
 
 func (dp *DudePanel) Update(o *UIOptions) {
@@ -996,16 +1162,1091 @@ func (dp *DudePanel) Check(mx, my float64, kind UICheckKind) bool {
 		}
 		return true
 	}
+	if dp.reviveButton.Check(mx, my, kind) {
+		if kind == UICheckClick && dp.onReviveClick != nil {
+			dp.onReviveClick()
+		}
+		return true
+	}
 	return false
 }
 
 func (dp *DudePanel) Draw(o *render.Options) {
 	dp.buyButton.Draw(o)
 	dp.fillButton.Draw(o)
+	dp.reviveButton.Draw(o)
 	dp.panel.Draw(o)
 	dp.count.Draw(o)
 }
 
+// TavernPanel shows the dudes available for hire.
+type TavernPanel struct {
+	panel          *UIPanel
+	title          *UIText
+	candidates     []*ButtonPanel
+	refreshButton  *ButtonPanel
+	hidden         bool
+	onHireClick    func(index int)
+	onRefreshClick func()
+	options        *UIOptions
+}
+
+func MakeTavernPanel() TavernPanel {
+	tp := TavernPanel{
+		panel:  NewUIPanel(PanelStyleNormal),
+		title:  NewUIText("Tavern", assets.DisplayFont, assets.ColorHeading),
+		hidden: true,
+	}
+
+	refresh := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	tp.refreshButton = &refresh
+	tp.refreshButton.text.center = true
+	tp.refreshButton.text.SetText("Refresh")
+
+	tp.panel.AddChild(tp.title)
+	tp.panel.centerChildren = true
+	return tp
+}
+
+// SetCandidates shows the candidates and what they cost to hire.
+func (tp *TavernPanel) SetCandidates(candidates []*Candidate, baseCost int) {
+	tp.candidates = nil
+	for index, c := range candidates {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.SetText(fmt.Sprintf("%s\nHire for %dgp", c.Description(), c.Cost(baseCost)))
+		if c.quality > EquipmentQualityCommon {
+			btn.text.textOptions.Color = c.quality.TextColor()
+		}
+		btn.onClick = func() {
+			if tp.onHireClick != nil {
+				tp.onHireClick(index)
+			}
+		}
+		tp.candidates = append(tp.candidates, &btn)
+	}
+	if tp.options != nil {
+		tp.Layout(tp.options)
+	}
+}
+
+func (tp *TavernPanel) Layout(o *UIOptions) {
+	tp.options = o
+	tp.panel.padding = 6 * o.Scale
+	tp.title.Layout(nil, o)
+
+	// Size to fit the candidates stacked under the title.
+	width := tp.title.Width()
+	height := tp.title.Height()
+	for _, c := range tp.candidates {
+		c.Layout(nil, o)
+		width = max(width, c.Width())
+		height += c.Height() + 2*o.Scale
+	}
+	tp.refreshButton.Layout(nil, o)
+	width = max(width, tp.refreshButton.Width())
+	height += tp.refreshButton.Height()
+
+	tp.panel.SetSize(width+tp.panel.padding*2, height+tp.panel.padding*2)
+	tp.panel.SetPosition(float64(o.Width)/2-tp.panel.Width()/2, float64(o.Height)/2-tp.panel.Height()/2)
+	tp.panel.Layout(nil, o)
+
+	y := tp.title.Y() + tp.title.Height()
+	for _, c := range tp.candidates {
+		c.SetPosition(tp.panel.X()+tp.panel.Width()/2-c.Width()/2, y)
+		c.panel.Layout(nil, o)
+		y += c.Height() + 2*o.Scale
+	}
+	tp.refreshButton.SetPosition(tp.panel.X()+tp.panel.Width()/2-tp.refreshButton.Width()/2, y)
+	tp.refreshButton.panel.Layout(nil, o)
+}
+
+func (tp *TavernPanel) Update(o *UIOptions) {
+	if tp.hidden {
+		return
+	}
+	tp.panel.Update(o)
+}
+
+func (tp *TavernPanel) Check(mx, my float64, kind UICheckKind) bool {
+	if tp.hidden {
+		return false
+	}
+	for _, c := range tp.candidates {
+		if c.Check(mx, my, kind) {
+			return true
+		}
+	}
+	if tp.refreshButton.Check(mx, my, kind) {
+		if kind == UICheckClick && tp.onRefreshClick != nil {
+			tp.onRefreshClick()
+		}
+		return true
+	}
+	return tp.panel.Check(mx, my, kind)
+}
+
+func (tp *TavernPanel) Draw(o *render.Options) {
+	if tp.hidden {
+		return
+	}
+	tp.panel.Draw(o)
+	for _, c := range tp.candidates {
+		c.Draw(o)
+	}
+	tp.refreshButton.Draw(o)
+}
+
+// ShopPanel shows the equipment for sale this build phase.
+type ShopPanel struct {
+	panel          *UIPanel
+	title          *UIText
+	items          []*ButtonPanel
+	stock          []*ShopItem
+	buyButton      *ButtonPanel
+	reserveButton  *ButtonPanel
+	refreshButton  *ButtonPanel
+	selected       int
+	hidden         bool
+	onBuyClick     func(index int)
+	onReserveClick func(index int)
+	onRefreshClick func()
+	options        *UIOptions
+}
+
+func NewShopPanel() *ShopPanel {
+	sp := &ShopPanel{
+		panel:    NewUIPanel(PanelStyleNormal),
+		title:    NewUIText("Shop", assets.DisplayFont, assets.ColorHeading),
+		selected: -1,
+		hidden:   true,
+	}
+
+	buy := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	sp.buyButton = &buy
+	sp.buyButton.text.center = true
+	sp.buyButton.text.SetText("Buy")
+	sp.buyButton.onClick = func() {
+		if sp.onBuyClick != nil && sp.selected >= 0 {
+			sp.onBuyClick(sp.selected)
+		}
+	}
+
+	reserve := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	sp.reserveButton = &reserve
+	sp.reserveButton.text.center = true
+	sp.reserveButton.text.SetText("Reserve")
+	sp.reserveButton.onClick = func() {
+		if sp.onReserveClick != nil && sp.selected >= 0 {
+			sp.onReserveClick(sp.selected)
+		}
+	}
+
+	refresh := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	sp.refreshButton = &refresh
+	sp.refreshButton.text.center = true
+	sp.refreshButton.text.SetText("Refresh")
+	sp.refreshButton.onClick = func() {
+		if sp.onRefreshClick != nil {
+			sp.onRefreshClick()
+		}
+	}
+
+	sp.panel.AddChild(sp.title)
+	sp.panel.centerChildren = true
+	return sp
+}
+
+// SetStock shows the items for sale, keeping the selection if it is still in stock.
+func (sp *ShopPanel) SetStock(stock []*ShopItem, refreshCost int) {
+	sp.stock = stock
+	sp.items = nil
+	for index, si := range stock {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.SetText(si.Description())
+		btn.text.textOptions.Color = si.equipment.TextColor()
+		btn.onClick = func() {
+			sp.Select(index)
+		}
+		sp.items = append(sp.items, &btn)
+	}
+	if len(sp.items) == 0 {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.SetText("Sold out! Refresh for more")
+		sp.items = append(sp.items, &btn)
+		sp.selected = -1
+	} else if sp.selected >= len(stock) {
+		sp.selected = len(stock) - 1
+	}
+	sp.refreshButton.text.SetText(fmt.Sprintf("Refresh\n%dgp", refreshCost))
+	sp.syncSelected()
+	if sp.options != nil {
+		sp.Layout(sp.options)
+	}
+}
+
+// Select picks the item to buy or reserve.
+func (sp *ShopPanel) Select(index int) {
+	sp.selected = index
+	sp.syncSelected()
+	if sp.options != nil {
+		sp.Layout(sp.options)
+	}
+}
+
+func (sp *ShopPanel) syncSelected() {
+	if sp.selected < 0 || sp.selected >= len(sp.stock) {
+		sp.buyButton.hidden = true
+		sp.reserveButton.hidden = true
+		return
+	}
+	sp.buyButton.hidden = false
+	sp.reserveButton.hidden = false
+	if sp.stock[sp.selected].reserved {
+		sp.reserveButton.text.SetText("Release\nreservation")
+	} else {
+		sp.reserveButton.text.SetText("Reserve\nkeeps it through restocks")
+	}
+}
+
+func (sp *ShopPanel) Layout(o *UIOptions) {
+	sp.options = o
+	sp.panel.padding = 6 * o.Scale
+	sp.title.Layout(nil, o)
+
+	// Size to fit the items stacked under the title, with the buy and reserve buttons side by side under them.
+	width := sp.title.Width()
+	height := sp.title.Height()
+	for _, item := range sp.items {
+		item.Layout(nil, o)
+		width = max(width, item.Width())
+		height += item.Height() + 2*o.Scale
+	}
+	sp.buyButton.Layout(nil, o)
+	sp.reserveButton.Layout(nil, o)
+	sp.refreshButton.Layout(nil, o)
+	width = max(width, sp.buyButton.Width()+sp.reserveButton.Width()+sp.refreshButton.Width()+4*o.Scale)
+	height += max(sp.buyButton.Height(), sp.reserveButton.Height(), sp.refreshButton.Height())
+
+	sp.panel.SetSize(width+sp.panel.padding*2, height+sp.panel.padding*2)
+	sp.panel.SetPosition(float64(o.Width)/2-sp.panel.Width()/2, float64(o.Height)/2-sp.panel.Height()/2)
+	sp.panel.Layout(nil, o)
+
+	y := sp.title.Y() + sp.title.Height()
+	for _, item := range sp.items {
+		item.SetPosition(sp.panel.X()+sp.panel.Width()/2-item.Width()/2, y)
+		item.panel.Layout(nil, o)
+		y += item.Height() + 2*o.Scale
+	}
+	x := sp.panel.X() + sp.panel.padding
+	for _, b := range []*ButtonPanel{sp.buyButton, sp.reserveButton, sp.refreshButton} {
+		b.SetPosition(x, y)
+		b.panel.Layout(nil, o)
+		x += b.Width() + 2*o.Scale
+	}
+}
+
+func (sp *ShopPanel) Update(o *UIOptions) {
+	if sp.hidden {
+		return
+	}
+	sp.panel.Update(o)
+}
+
+func (sp *ShopPanel) Check(mx, my float64, kind UICheckKind) bool {
+	if sp.hidden {
+		return false
+	}
+	for _, item := range sp.items {
+		if item.Check(mx, my, kind) {
+			return true
+		}
+	}
+	if !sp.buyButton.hidden && sp.buyButton.Check(mx, my, kind) {
+		return true
+	}
+	if !sp.reserveButton.hidden && sp.reserveButton.Check(mx, my, kind) {
+		return true
+	}
+	if sp.refreshButton.Check(mx, my, kind) {
+		return true
+	}
+	return sp.panel.Check(mx, my, kind)
+}
+
+func (sp *ShopPanel) Draw(o *render.Options) {
+	if sp.hidden {
+		return
+	}
+	sp.panel.Draw(o)
+	for _, item := range sp.items {
+		item.Draw(o)
+	}
+	if sp.selected >= 0 && sp.selected < len(sp.items) {
+		item := sp.items[sp.selected]
+		x, y := item.Position()
+		w, h := item.Size()
+		vector.StrokeRect(o.Screen, float32(x), float32(y), float32(w), float32(h), 1, assets.ColorSelected, false)
+	}
+	if !sp.buyButton.hidden {
+		sp.buyButton.Draw(o)
+		sp.reserveButton.Draw(o)
+	}
+	sp.refreshButton.Draw(o)
+}
+
+// TreasuryPanel shows the tax rate and the ledger of gold earned and spent.
+type TreasuryPanel struct {
+	panel        *UIPanel
+	title        *UIText
+	taxText      *UIText
+	ledger       *UIText
+	toggleButton *ButtonPanel
+	lowerButton  *ButtonPanel
+	raiseButton  *ButtonPanel
+	hidden       bool
+	onLowerClick func()
+	onRaiseClick func()
+}
+
+func NewTreasuryPanel() *TreasuryPanel {
+	tp := &TreasuryPanel{
+		panel:   NewUIPanel(PanelStyleNormal),
+		title:   NewUIText("Treasury", assets.DisplayFont, assets.ColorHeading),
+		taxText: NewUIText("tax 0%", assets.BodyFont, assets.ColorGold),
+		ledger:  NewUIText("", assets.BodyFont, assets.ColorHeading),
+		hidden:  true,
+	}
+
+	toggle := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	tp.toggleButton = &toggle
+	tp.toggleButton.text.center = true
+	tp.toggleButton.text.SetText("Treasury")
+	tp.toggleButton.onClick = func() {
+		tp.hidden = !tp.hidden
+	}
+
+	lower := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	tp.lowerButton = &lower
+	tp.lowerButton.text.center = true
+	tp.lowerButton.text.SetText("-")
+
+	raise := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	tp.raiseButton = &raise
+	tp.raiseButton.text.center = true
+	tp.raiseButton.text.SetText("+")
+
+	tp.panel.AddChild(tp.title)
+	tp.panel.AddChild(tp.taxText)
+	tp.panel.AddChild(tp.ledger)
+	return tp
+}
+
+// SetTaxRate shows the tax rate.
+func (tp *TreasuryPanel) SetTaxRate(rate float64) {
+	tp.taxText.SetText(fmt.Sprintf("tax %d%%", int(math.Round(rate*100))))
+	tp.toggleButton.text.SetText(fmt.Sprintf("Treasury\ntax %d%%", int(math.Round(rate*100))))
+}
+
+// SetLedger shows the ledger summary.
+func (tp *TreasuryPanel) SetLedger(summary string) {
+	tp.ledger.SetText(summary)
+}
+
+func (tp *TreasuryPanel) Layout(o *UIOptions) {
+	tp.toggleButton.Layout(nil, o)
+	tp.lowerButton.Layout(nil, o)
+	tp.raiseButton.Layout(nil, o)
+
+	tp.panel.padding = 6 * o.Scale
+	tp.title.Layout(nil, o)
+	tp.taxText.Layout(nil, o)
+	tp.ledger.Layout(nil, o)
+
+	// Size to fit the texts with room for the tax buttons.
+	width := max(tp.title.Width(), tp.taxText.Width()+tp.lowerButton.Width()+tp.raiseButton.Width(), tp.ledger.Width())
+	height := tp.title.Height() + max(tp.taxText.Height(), tp.lowerButton.Height()) + tp.ledger.Height()
+	tp.panel.SetSize(width+tp.panel.padding*2, height+tp.panel.padding*2)
+
+	x := math.Min(tp.toggleButton.X(), float64(o.Width)-tp.panel.Width()-8)
+	tp.panel.SetPosition(x, tp.toggleButton.Y()+tp.toggleButton.Height()+4*o.Scale)
+	tp.panel.Layout(nil, o)
+
+	// Tax buttons go either side of the tax text, with the ledger below them.
+	y := tp.title.Y() + tp.title.Height()
+	tp.lowerButton.SetPosition(tp.panel.X()+tp.panel.padding, y)
+	tp.lowerButton.panel.Layout(nil, o)
+	tp.taxText.SetPosition(tp.lowerButton.X()+tp.lowerButton.Width(), y+tp.lowerButton.Height()/2-tp.taxText.Height()/2)
+	tp.raiseButton.SetPosition(tp.taxText.X()+tp.taxText.Width(), y)
+	tp.raiseButton.panel.Layout(nil, o)
+	tp.ledger.SetPosition(tp.panel.X()+tp.panel.padding, y+max(tp.taxText.Height(), tp.lowerButton.Height()))
+}
+
+func (tp *TreasuryPanel) Update(o *UIOptions) {
+	tp.toggleButton.Update(o)
+	if tp.hidden {
+		return
+	}
+	tp.panel.Update(o)
+}
+
+func (tp *TreasuryPanel) Check(mx, my float64, kind UICheckKind) bool {
+	if tp.toggleButton.Check(mx, my, kind) {
+		return true
+	}
+	if tp.hidden {
+		return false
+	}
+	if tp.lowerButton.Check(mx, my, kind) {
+		if kind == UICheckClick && tp.onLowerClick != nil {
+			tp.onLowerClick()
+		}
+		return true
+	}
+	if tp.raiseButton.Check(mx, my, kind) {
+		if kind == UICheckClick && tp.onRaiseClick != nil {
+			tp.onRaiseClick()
+		}
+		return true
+	}
+	return tp.panel.Check(mx, my, kind)
+}
+
+func (tp *TreasuryPanel) Draw(o *render.Options) {
+	tp.toggleButton.Draw(o)
+	if tp.hidden {
+		return
+	}
+	tp.panel.Draw(o)
+	tp.lowerButton.Draw(o)
+	tp.raiseButton.Draw(o)
+}
+
+// ForgePanel shows the forge recipes for the stash, and salvaging and reforging for the selected item.
+type ForgePanel struct {
+	panel          *UIPanel
+	title          *UIText
+	materials      *UIText
+	recipes        []*ButtonPanel
+	salvageButton  *ButtonPanel
+	reforgeButton  *ButtonPanel
+	toggleButton   *ButtonPanel
+	hidden         bool
+	onForgeClick   func(index int)
+	onSalvageClick func()
+	onReforgeClick func()
+	onToggle       func()
+	options        *UIOptions
+}
+
+func NewForgePanel() *ForgePanel {
+	fp := &ForgePanel{
+		panel:     NewUIPanel(PanelStyleNormal),
+		title:     NewUIText("Forge", assets.DisplayFont, assets.ColorHeading),
+		materials: NewUIText("0 materials", assets.BodyFont, assets.ColorItemLevel),
+		hidden:    true,
+	}
+
+	toggle := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	fp.toggleButton = &toggle
+	fp.toggleButton.text.center = true
+	fp.toggleButton.text.SetText("Forge")
+	fp.toggleButton.onClick = func() {
+		fp.hidden = !fp.hidden
+		if fp.onToggle != nil {
+			fp.onToggle()
+		}
+	}
+
+	salvage := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	fp.salvageButton = &salvage
+	fp.salvageButton.text.center = true
+	fp.salvageButton.onClick = func() {
+		if fp.onSalvageClick != nil {
+			fp.onSalvageClick()
+		}
+	}
+
+	reforge := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	fp.reforgeButton = &reforge
+	fp.reforgeButton.text.center = true
+	fp.reforgeButton.onClick = func() {
+		if fp.onReforgeClick != nil {
+			fp.onReforgeClick()
+		}
+	}
+
+	fp.panel.AddChild(fp.title)
+	fp.panel.AddChild(fp.materials)
+	fp.panel.centerChildren = true
+	return fp
+}
+
+// SetForge shows the forge recipes, the materials on hand and what can be done with the selected item.
+func (fp *ForgePanel) SetForge(groups []ForgeGroup, selected *Equipment, materials int) {
+	fp.materials.SetText(fmt.Sprintf("%d materials", materials))
+	fp.toggleButton.text.SetText(fmt.Sprintf("Forge\n%d mats", materials))
+
+	fp.recipes = nil
+	for index, fg := range groups {
+		if index >= ForgeMaxGroups {
+			break
+		}
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.SetText(fg.Preview())
+		btn.text.textOptions.Color = (fg.quality + 1).TextColor()
+		btn.onClick = func() {
+			if fp.onForgeClick != nil {
+				fp.onForgeClick(index)
+			}
+		}
+		fp.recipes = append(fp.recipes, &btn)
+	}
+	if len(fp.recipes) == 0 {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.SetText(fmt.Sprintf("Stash %d of the same item and quality to forge", ForgeCount))
+		fp.recipes = append(fp.recipes, &btn)
+	}
+
+	if selected == nil {
+		fp.salvageButton.hidden = true
+		fp.reforgeButton.hidden = true
+	} else {
+		fp.salvageButton.hidden = false
+		fp.salvageButton.text.SetText(selected.SalvagePreview())
+		fp.reforgeButton.hidden = selected.IsConsumable() || selected.IsUnique()
+		fp.reforgeButton.text.SetText(selected.ReforgePreview())
+	}
+
+	if fp.options != nil {
+		fp.Layout(fp.options)
+	}
+}
+
+func (fp *ForgePanel) Layout(o *UIOptions) {
+	fp.options = o
+	fp.panel.padding = 6 * o.Scale
+	fp.title.Layout(nil, o)
+	fp.materials.Layout(nil, o)
+
+	// Size to fit the recipes and item buttons stacked under the title.
+	buttons := append([]*ButtonPanel{}, fp.recipes...)
+	if !fp.salvageButton.hidden {
+		buttons = append(buttons, fp.salvageButton, fp.reforgeButton)
+	}
+	width := max(fp.title.Width(), fp.materials.Width())
+	height := fp.title.Height() + fp.materials.Height()
+	for _, b := range buttons {
+		b.Layout(nil, o)
+		width = max(width, b.Width())
+		height += b.Height() + 2*o.Scale
+	}
+
+	fp.panel.SetSize(width+fp.panel.padding*2, height+fp.panel.padding*2)
+	fp.panel.SetPosition(float64(o.Width)/2-fp.panel.Width()/2, float64(o.Height)/2-fp.panel.Height()/2)
+	fp.panel.Layout(nil, o)
+
+	y := fp.materials.Y() + fp.materials.Height()
+	for _, b := range buttons {
+		b.SetPosition(fp.panel.X()+fp.panel.Width()/2-b.Width()/2, y)
+		b.panel.Layout(nil, o)
+		y += b.Height() + 2*o.Scale
+	}
+}
+
+func (fp *ForgePanel) Update(o *UIOptions) {
+	fp.toggleButton.Update(o)
+	if fp.hidden {
+		return
+	}
+	fp.panel.Update(o)
+}
+
+func (fp *ForgePanel) Check(mx, my float64, kind UICheckKind) bool {
+	if fp.onToggle != nil && fp.toggleButton.Check(mx, my, kind) {
+		return true
+	}
+	if fp.hidden {
+		return false
+	}
+	for _, r := range fp.recipes {
+		if r.Check(mx, my, kind) {
+			return true
+		}
+	}
+	if !fp.salvageButton.hidden && fp.salvageButton.Check(mx, my, kind) {
+		return true
+	}
+	if !fp.reforgeButton.hidden && fp.reforgeButton.Check(mx, my, kind) {
+		return true
+	}
+	return fp.panel.Check(mx, my, kind)
+}
+
+func (fp *ForgePanel) Draw(o *render.Options) {
+	// Forging only happens between runs.
+	if fp.onToggle == nil {
+		return
+	}
+	fp.toggleButton.Draw(o)
+	if fp.hidden {
+		return
+	}
+	fp.panel.Draw(o)
+	for _, r := range fp.recipes {
+		r.Draw(o)
+	}
+	if !fp.salvageButton.hidden {
+		fp.salvageButton.Draw(o)
+		fp.reforgeButton.Draw(o)
+	}
+}
+
+// LootRulesPanel configures the loot policy used by auto-equip, sell all and autoplay.
+type LootRulesPanel struct {
+	panel             *UIPanel
+	title             *UIText
+	priorityHeading   *UIText
+	keepHeading       *UIText
+	spreadButton      *ButtonPanel
+	sellButton        *ButtonPanel
+	professionButtons []*ButtonPanel
+	statButtons       []*ButtonPanel
+	perkButtons       []*ButtonPanel
+	perks             []string
+	toggleButton      *ButtonPanel
+	policy            *LootPolicy
+	selected          int // Profession whose priority stats are being set.
+	hidden            bool
+	onSpreadClick     func()
+	onSellClick       func()
+	onPriorityClick   func(pk ProfessionKind, stat Stat)
+	onKeepClick       func(name string)
+	onToggle          func()
+	options           *UIOptions
+}
+
+func NewLootRulesPanel() *LootRulesPanel {
+	lp := &LootRulesPanel{
+		panel:           NewUIPanel(PanelStyleNormal),
+		title:           NewUIText("Loot Rules", assets.DisplayFont, assets.ColorHeading),
+		priorityHeading: NewUIText("Priority stats", assets.BodyFont, assets.ColorHeading),
+		keepHeading:     NewUIText("Always keep", assets.BodyFont, assets.ColorHeading),
+		perks:           PolicyPerks(),
+		hidden:          true,
+	}
+
+	toggle := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	lp.toggleButton = &toggle
+	lp.toggleButton.text.center = true
+	lp.toggleButton.text.SetText("Loot\nRules")
+	lp.toggleButton.onClick = func() {
+		lp.hidden = !lp.hidden
+		if lp.onToggle != nil {
+			lp.onToggle()
+		}
+	}
+
+	spread := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	lp.spreadButton = &spread
+	lp.spreadButton.text.center = true
+	lp.spreadButton.onClick = func() {
+		if lp.onSpreadClick != nil {
+			lp.onSpreadClick()
+		}
+	}
+
+	sell := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	lp.sellButton = &sell
+	lp.sellButton.text.center = true
+	lp.sellButton.onClick = func() {
+		if lp.onSellClick != nil {
+			lp.onSellClick()
+		}
+	}
+
+	for index := range PolicyProfessions {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.center = true
+		btn.onClick = func() {
+			lp.selected = index
+			if lp.policy != nil {
+				lp.SetPolicy(lp.policy)
+			}
+		}
+		lp.professionButtons = append(lp.professionButtons, &btn)
+	}
+
+	for _, stat := range PolicyStats {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.center = true
+		btn.text.SetText(string(stat))
+		btn.onClick = func() {
+			if lp.onPriorityClick != nil {
+				lp.onPriorityClick(PolicyProfessions[lp.selected], stat)
+			}
+		}
+		lp.statButtons = append(lp.statButtons, &btn)
+	}
+
+	for _, name := range lp.perks {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.center = true
+		btn.text.SetText(name)
+		btn.onClick = func() {
+			if lp.onKeepClick != nil {
+				lp.onKeepClick(name)
+			}
+		}
+		lp.perkButtons = append(lp.perkButtons, &btn)
+	}
+
+	lp.panel.AddChild(lp.title)
+	lp.panel.centerChildren = true
+	return lp
+}
+
+// SetPolicy shows the rules of the loot policy, lighting up the priority stats of the selected profession and the kept perks.
+func (lp *LootRulesPanel) SetPolicy(policy *LootPolicy) {
+	lp.policy = policy
+	lp.spreadButton.text.SetText(policy.SpreadString())
+	lp.sellButton.text.SetText(policy.SellString())
+	lp.sellButton.text.textOptions.Color = policy.MaxSellQuality.TextColor()
+
+	for i, btn := range lp.professionButtons {
+		pk := PolicyProfessions[i]
+		btn.text.SetText(fmt.Sprintf("%s\n%s", pk.String(), policy.PrioritiesString(pk)))
+	}
+	priorities := policy.Priorities(PolicyProfessions[lp.selected])
+	for i, btn := range lp.statButtons {
+		if slices.Contains(priorities, PolicyStats[i]) {
+			btn.text.textOptions.Color = assets.ColorStatUp
+		} else {
+			btn.text.textOptions.Color = assets.ColorItemDescription
+		}
+	}
+	for i, btn := range lp.perkButtons {
+		if slices.Contains(policy.KeepPerks, lp.perks[i]) {
+			btn.text.textOptions.Color = assets.ColorStatUp
+		} else {
+			btn.text.textOptions.Color = assets.ColorItemDescription
+		}
+	}
+
+	if lp.options != nil {
+		lp.Layout(lp.options)
+	}
+}
+
+// rows returns the buttons in the rows they are laid out in, perks four to a row.
+func (lp *LootRulesPanel) rows() [][]*ButtonPanel {
+	rows := [][]*ButtonPanel{{lp.spreadButton, lp.sellButton}, lp.professionButtons, lp.statButtons}
+	for i := 0; i < len(lp.perkButtons); i += 4 {
+		rows = append(rows, lp.perkButtons[i:min(i+4, len(lp.perkButtons))])
+	}
+	return rows
+}
+
+func (lp *LootRulesPanel) Layout(o *UIOptions) {
+	lp.options = o
+	lp.panel.padding = 6 * o.Scale
+	lp.title.Layout(nil, o)
+	lp.priorityHeading.Layout(nil, o)
+	lp.keepHeading.Layout(nil, o)
+
+	// Size to fit the rows of buttons, with the headings above the priority stats and kept perks.
+	rows := lp.rows()
+	width := lp.title.Width()
+	height := lp.title.Height() + lp.priorityHeading.Height() + lp.keepHeading.Height()
+	for _, row := range rows {
+		rowWidth, rowHeight := 0.0, 0.0
+		for _, b := range row {
+			b.Layout(nil, o)
+			rowWidth += b.Width() + 2*o.Scale
+			rowHeight = max(rowHeight, b.Height())
+		}
+		width = max(width, rowWidth)
+		height += rowHeight + 2*o.Scale
+	}
+
+	lp.panel.SetSize(width+lp.panel.padding*2, height+lp.panel.padding*2)
+	lp.panel.SetPosition(float64(o.Width)/2-lp.panel.Width()/2, float64(o.Height)/2-lp.panel.Height()/2)
+	lp.panel.Layout(nil, o)
+
+	y := lp.title.Y() + lp.title.Height()
+	for i, row := range rows {
+		switch i {
+		case 1:
+			lp.priorityHeading.SetPosition(lp.panel.X()+lp.panel.padding, y)
+			y += lp.priorityHeading.Height()
+		case 3:
+			lp.keepHeading.SetPosition(lp.panel.X()+lp.panel.padding, y)
+			y += lp.keepHeading.Height()
+		}
+		x := lp.panel.X() + lp.panel.padding
+		rowHeight := 0.0
+		for _, b := range row {
+			b.SetPosition(x, y)
+			b.panel.Layout(nil, o)
+			x += b.Width() + 2*o.Scale
+			rowHeight = max(rowHeight, b.Height())
+		}
+		y += rowHeight + 2*o.Scale
+	}
+}
+
+func (lp *LootRulesPanel) Update(o *UIOptions) {
+	lp.toggleButton.Update(o)
+	if lp.hidden {
+		return
+	}
+	lp.panel.Update(o)
+}
+
+func (lp *LootRulesPanel) Check(mx, my float64, kind UICheckKind) bool {
+	if lp.onToggle != nil && lp.toggleButton.Check(mx, my, kind) {
+		return true
+	}
+	if lp.hidden {
+		return false
+	}
+	for _, row := range lp.rows() {
+		for _, b := range row {
+			if b.Check(mx, my, kind) {
+				return true
+			}
+		}
+	}
+	return lp.panel.Check(mx, my, kind)
+}
+
+func (lp *LootRulesPanel) Draw(o *render.Options) {
+	// Rules are only set between runs.
+	if lp.onToggle == nil {
+		return
+	}
+	lp.toggleButton.Draw(o)
+	if lp.hidden {
+		return
+	}
+	lp.panel.Draw(o)
+	lp.priorityHeading.Draw(o)
+	lp.keepHeading.Draw(o)
+	for _, row := range lp.rows() {
+		for _, b := range row {
+			b.Draw(o)
+		}
+	}
+	selected := lp.professionButtons[lp.selected]
+	x, y := selected.Position()
+	w, h := selected.Size()
+	vector.StrokeRect(o.Screen, float32(x), float32(y), float32(w), float32(h), 1, assets.ColorSelected, false)
+}
+
+// PartyPanel shows the parties and when each sets out, and lets the player name them.
+type PartyPanel struct {
+	panel            *UIPanel
+	title            *UIText
+	parties          []*ButtonPanel
+	nameButtons      []*ButtonPanel
+	names            []string
+	editing          int // Index of the party whose name is being typed, -1 for none.
+	toggleButton     *ButtonPanel
+	hidden           bool
+	onDepartureClick func(index int)
+	onRename         func(index int, name string)
+}
+
+func NewPartyPanel() *PartyPanel {
+	pp := &PartyPanel{
+		panel:   NewUIPanel(PanelStyleNormal),
+		title:   NewUIText("Parties", assets.DisplayFont, assets.ColorHeading),
+		editing: -1,
+		hidden:  true,
+	}
+
+	toggle := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+	pp.toggleButton = &toggle
+	pp.toggleButton.text.center = true
+	pp.toggleButton.text.SetText("Parties")
+	pp.toggleButton.onClick = func() {
+		pp.hidden = !pp.hidden
+	}
+
+	for i := range PartyColors {
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		btn.text.textOptions.Color = PartyColors[i]
+		btn.onClick = func() {
+			if pp.onDepartureClick != nil {
+				pp.onDepartureClick(i)
+			}
+		}
+		pp.parties = append(pp.parties, &btn)
+
+		name := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
+		name.text.SetText("rename")
+		name.onClick = func() {
+			pp.ToggleEditing(i)
+		}
+		pp.nameButtons = append(pp.nameButtons, &name)
+		pp.names = append(pp.names, PartyName(i))
+	}
+
+	pp.panel.AddChild(pp.title)
+	return pp
+}
+
+// SetParties shows each party's name, dude count and departure.
+func (pp *PartyPanel) SetParties(parties []*Party, counts []int) {
+	for i, btn := range pp.parties {
+		if i >= len(parties) || i >= len(counts) {
+			continue
+		}
+		pp.names[i] = parties[i].Name()
+		name := pp.names[i]
+		if i == pp.editing {
+			name += "_"
+		}
+		btn.text.SetText(fmt.Sprintf("%s (%d)\n%s", name, counts[i], parties[i].DepartureString(parties)))
+	}
+}
+
+// Editing returns if a party's name is being typed in.
+func (pp *PartyPanel) Editing() bool {
+	return pp.editing >= 0
+}
+
+// ToggleEditing starts or stops typing in the name of the party at the given index.
+func (pp *PartyPanel) ToggleEditing(index int) {
+	editing := pp.editing
+	pp.StopEditing()
+	if editing == index {
+		return
+	}
+	pp.editing = index
+	pp.nameButtons[index].text.SetText("done")
+	pp.rename(index, pp.names[index])
+}
+
+// StopEditing stops typing in a party's name, going back to the default name if it was left empty.
+func (pp *PartyPanel) StopEditing() {
+	if pp.editing < 0 {
+		return
+	}
+	index := pp.editing
+	pp.editing = -1
+	pp.nameButtons[index].text.SetText("rename")
+	name := strings.TrimSpace(pp.names[index])
+	if name == "" {
+		name = PartyName(index)
+	}
+	pp.rename(index, name)
+}
+
+func (pp *PartyPanel) rename(index int, name string) {
+	if pp.onRename != nil {
+		pp.onRename(index, name)
+	}
+}
+
+// UpdateEditing types into the name being edited, enter or escape finishes it.
+func (pp *PartyPanel) UpdateEditing() {
+	if pp.hidden || inpututil.IsKeyJustPressed(ebiten.KeyEnter) || inpututil.IsKeyJustPressed(ebiten.KeyKPEnter) || inpututil.IsKeyJustPressed(ebiten.KeyEscape) {
+		pp.StopEditing()
+		return
+	}
+	name := []rune(pp.names[pp.editing])
+	changed := false
+	if inpututil.IsKeyJustPressed(ebiten.KeyBackspace) && len(name) > 0 {
+		name = name[:len(name)-1]
+		changed = true
+	}
+	for _, r := range ebiten.AppendInputChars(nil) {
+		if len(name) < PartyMaxNameLength {
+			name = append(name, r)
+			changed = true
+		}
+	}
+	if changed {
+		pp.rename(pp.editing, string(name))
+	}
+}
+
+func (pp *PartyPanel) Layout(o *UIOptions) {
+	pp.panel.padding = 6 * o.Scale
+	pp.title.Layout(nil, o)
+
+	// Size to fit the parties stacked under the title, each with its rename button beside it.
+	partyWidth, nameWidth := 0.0, 0.0
+	height := pp.title.Height()
+	for i, p := range pp.parties {
+		p.Layout(nil, o)
+		pp.nameButtons[i].Layout(nil, o)
+		partyWidth = max(partyWidth, p.Width())
+		nameWidth = max(nameWidth, pp.nameButtons[i].Width())
+		height += p.Height() + 2*o.Scale
+	}
+	width := max(pp.title.Width(), partyWidth+2*o.Scale+nameWidth)
+	pp.panel.SetSize(width+pp.panel.padding*2, height+pp.panel.padding*2)
+
+	x := math.Max(pp.toggleButton.X()+pp.toggleButton.Width()-pp.panel.Width(), 8)
+	pp.panel.SetPosition(x, pp.toggleButton.Y()+pp.toggleButton.Height()+4*o.Scale)
+	pp.panel.Layout(nil, o)
+
+	y := pp.title.Y() + pp.title.Height()
+	for i, p := range pp.parties {
+		p.SetPosition(pp.panel.X()+pp.panel.padding, y)
+		p.panel.Layout(nil, o)
+		pp.nameButtons[i].SetPosition(pp.panel.X()+pp.panel.padding+partyWidth+2*o.Scale, y)
+		pp.nameButtons[i].panel.Layout(nil, o)
+		y += p.Height() + 2*o.Scale
+	}
+}
+
+func (pp *PartyPanel) Update(o *UIOptions) {
+	if pp.Editing() {
+		pp.UpdateEditing()
+	}
+	pp.toggleButton.Update(o)
+	if pp.hidden {
+		return
+	}
+	pp.panel.Update(o)
+}
+
+func (pp *PartyPanel) Check(mx, my float64, kind UICheckKind) bool {
+	if pp.toggleButton.Check(mx, my, kind) {
+		return true
+	}
+	if pp.hidden {
+		return false
+	}
+	for _, p := range pp.parties {
+		if p.Check(mx, my, kind) {
+			return true
+		}
+	}
+	for _, p := range pp.nameButtons {
+		if p.Check(mx, my, kind) {
+			return true
+		}
+	}
+	return pp.panel.Check(mx, my, kind)
+}
+
+func (pp *PartyPanel) Draw(o *render.Options) {
+	pp.toggleButton.Draw(o)
+	if pp.hidden {
+		return
+	}
+	pp.panel.Draw(o)
+	for _, p := range pp.parties {
+		p.Draw(o)
+	}
+	for _, p := range pp.nameButtons {
+		p.Draw(o)
+	}
+}
+
 type DudeInfoPanel struct {
 	panel       *UIPanel
 	title       *UIText
@@ -1015,8 +2256,10 @@ type DudeInfoPanel struct {
 	dude *Dude
 
 	level      *UIText
+	traits     *UIText
 	xp         *UIText
 	hp         *UIText
+	morale     *UIText
 	strength   *UIText
 	agility    *UIText
 	defense    *UIText
@@ -1025,14 +2268,29 @@ type DudeInfoPanel struct {
 	luck       *UIText
 
 	equipmentPanel *UIPanel
-	armorText      *UIText
-	weaponText     *UIText
-	accessoryText  *UIText
+	slotTexts      []*UIText // One per equipment slot
 
 	equipmentDetails *EquipmentDetailsPanel
 	showDetails      bool
 
-	whichSelect int
+	skillsPanel  *UIPanel
+	skillsTitle  *UIText
+	skillTexts   []*UIText
+	skills       []SkillKind
+	onSkillClick func(sk SkillKind)
+
+	promoteButton  *ButtonPanel
+	onPromoteClick func()
+
+	behaviorButton *ButtonPanel
+
+	beltButton *ButtonPanel
+
+	partyButton  *ButtonPanel
+	onPartyClick func()
+	parties      []*Party // For the names of the parties.
+
+	whichSelect int // Selected equipment slot + 1, 0 for none
 
 	small  bool
 	hidden bool
@@ -1043,8 +2301,10 @@ func NewDudeInfoPanel() *DudeInfoPanel {
 		panel:            NewUIPanel(PanelStyleTransparent),
 		title:            NewUIText("Mah Dude", assets.DisplayFont, assets.ColorDudeTitle),
 		level:            NewUIText("Level 0 sucker", assets.BodyFont, assets.ColorDudeLevel),
+		traits:           NewUIText("", assets.BodyFont, assets.ColorDudeLuck),
 		xp:               NewUIText("0/0 xp", assets.BodyFont, assets.ColorDudeXP),
 		hp:               NewUIText("0/0 hp", assets.BodyFont, assets.ColorDudeHP),
+		morale:           NewUIText("0 morale", assets.BodyFont, assets.ColorDudeConfidence),
 		strength:         NewUIText("0 strength", assets.BodyFont, assets.ColorDudeStrength),
 		agility:          NewUIText("0 agility", assets.BodyFont, assets.ColorDudeAgility),
 		defense:          NewUIText("0 defense", assets.BodyFont, assets.ColorDudeDefense),
@@ -1052,10 +2312,9 @@ func NewDudeInfoPanel() *DudeInfoPanel {
 		confidence:       NewUIText("0 confidence", assets.BodyFont, assets.ColorDudeConfidence),
 		luck:             NewUIText("0 luck", assets.BodyFont, assets.ColorDudeLuck),
 		equipmentPanel:   NewUIPanel(PanelStyleInteractive),
-		armorText:        NewUIText("    nakie", assets.BodyFont, assets.ColorDudeDefense),
-		weaponText:       NewUIText("    fists", assets.BodyFont, assets.ColorDudeStrength),
-		accessoryText:    NewUIText("    nada", assets.BodyFont, assets.ColorDudeWisdom),
 		equipmentDetails: NewEquipmentDetailsPanel(true),
+		skillsPanel:      NewUIPanel(PanelStyleInteractive),
+		skillsTitle:      NewUIText("Skills", assets.DisplayFont, assets.ColorHeading),
 		hidden:           false,
 	}
 	dip.panel.AddChild(dip.title)
@@ -1066,52 +2325,104 @@ func NewDudeInfoPanel() *DudeInfoPanel {
 	txt.ignoreScale = true
 	dip.equipmentPanel.sizeChildren = true
 	dip.equipmentPanel.AddChild(txt)
-	dip.armorText.ignoreScale = true
-	dip.weaponText.ignoreScale = true
-	dip.accessoryText.ignoreScale = true
-	dip.armorText.onCheck = func(kind UICheckKind) {
-		if kind == UICheckClick {
-			dip.whichSelect = 1
-			if armor, ok := dip.dude.equipped[EquipmentTypeArmor]; ok {
-				dip.equipmentDetails.SetEquipment(armor)
-			} else {
-				dip.equipmentDetails.SetEquipment(nil)
+	for slot := EquipmentSlotHead; slot < EquipmentSlotEnd; slot++ {
+		slot := slot
+		txt := NewUIText(slot.Empty(), assets.BodyFont, slot.Color())
+		txt.ignoreScale = true
+		txt.onCheck = func(kind UICheckKind) {
+			if kind == UICheckClick {
+				dip.whichSelect = int(slot) + 1
+				if eq, ok := dip.dude.equipped[slot]; ok {
+					dip.equipmentDetails.SetEquipment(eq)
+				} else {
+					dip.equipmentDetails.SetEquipment(nil)
+				}
 			}
 		}
+		dip.slotTexts = append(dip.slotTexts, txt)
+		dip.equipmentPanel.AddChild(txt)
 	}
-	dip.weaponText.onCheck = func(kind UICheckKind) {
-		if kind == UICheckClick {
-			dip.whichSelect = 2
-			if weapon, ok := dip.dude.equipped[EquipmentTypeWeapon]; ok {
-				dip.equipmentDetails.SetEquipment(weapon)
-			} else {
-				dip.equipmentDetails.SetEquipment(nil)
+
+	dip.skillsPanel.sizeChildren = true
+	dip.skillsTitle.ignoreScale = true
+	dip.skillsPanel.AddChild(dip.skillsTitle)
+	for i := 0; i < 3; i++ {
+		i := i
+		txt := NewUIText("", assets.BodyFont, assets.ColorDudeLevel)
+		txt.ignoreScale = true
+		txt.onCheck = func(kind UICheckKind) {
+			if kind == UICheckClick && i < len(dip.skills) && dip.onSkillClick != nil {
+				dip.onSkillClick(dip.skills[i])
 			}
 		}
+		dip.skillTexts = append(dip.skillTexts, txt)
+		dip.skillsPanel.AddChild(txt)
 	}
-	dip.accessoryText.onCheck = func(kind UICheckKind) {
-		if kind == UICheckClick {
-			dip.whichSelect = 3
-			if accessory, ok := dip.dude.equipped[EquipmentTypeAccessory]; ok {
-				dip.equipmentDetails.SetEquipment(accessory)
-			} else {
-				dip.equipmentDetails.SetEquipment(nil)
+
+	{
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButtonAttached)
+		dip.promoteButton = &btn
+		dip.promoteButton.text.center = true
+		dip.promoteButton.text.ignoreScale = true
+		dip.promoteButton.hidden = true
+		dip.promoteButton.onClick = func() {
+			if dip.onPromoteClick != nil {
+				dip.onPromoteClick()
+			}
+		}
+	}
+
+	{
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButtonAttached)
+		dip.behaviorButton = &btn
+		dip.behaviorButton.text.center = true
+		dip.behaviorButton.text.ignoreScale = true
+		dip.behaviorButton.onClick = func() {
+			if dip.dude != nil {
+				dip.dude.CycleBehavior()
+				dip.SyncDude()
+			}
+		}
+	}
+
+	{
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButtonAttached)
+		dip.beltButton = &btn
+		dip.beltButton.text.center = true
+		dip.beltButton.text.ignoreScale = true
+		dip.beltButton.onClick = func() {
+			if dip.dude != nil {
+				dip.dude.CycleUseThreshold()
+				dip.SyncDude()
+			}
+		}
+	}
+
+	{
+		btn := MakeButtonPanel(assets.BodyFont, PanelStyleButtonAttached)
+		dip.partyButton = &btn
+		dip.partyButton.text.center = true
+		dip.partyButton.text.ignoreScale = true
+		dip.partyButton.onClick = func() {
+			if dip.onPartyClick != nil {
+				dip.onPartyClick()
 			}
 		}
 	}
-	dip.equipmentPanel.AddChild(dip.armorText)
-	dip.equipmentPanel.AddChild(dip.weaponText)
-	dip.equipmentPanel.AddChild(dip.accessoryText)
 
 	dip.equipmentDetails.swapButton.text.SetText("Snarf\nLoot")
 	dip.equipmentDetails.hidden = true
 
 	dip.panel.AddChild(dip.level)
 	dip.level.ignoreScale = true
+	dip.panel.AddChild(dip.traits)
+	dip.traits.ignoreScale = true
 	dip.panel.AddChild(dip.xp)
 	dip.xp.ignoreScale = true
 	dip.panel.AddChild(dip.hp)
 	dip.hp.ignoreScale = true
+	dip.panel.AddChild(dip.morale)
+	dip.morale.ignoreScale = true
 	dip.panel.AddChild(dip.strength)
 	dip.strength.ignoreScale = true
 	dip.panel.AddChild(dip.agility)
@@ -1132,6 +2443,7 @@ func NewDudeInfoPanel() *DudeInfoPanel {
 
 func (dip *DudeInfoPanel) SetDude(dude *Dude) {
 	dip.dude = dude
+	dip.equipmentDetails.owner = dude
 	if dude == nil {
 		dip.hidden = true
 		return
@@ -1148,9 +2460,11 @@ func (dip *DudeInfoPanel) SyncDude() {
 	dip.title.SetText(dip.dude.Name())
 
 	dip.level.SetText(fmt.Sprintf("Level %d %s", dip.dude.Level(), dip.dude.Profession()))
+	dip.traits.SetText(TraitsString(dip.dude.Traits()))
 	dip.xp.SetText(fmt.Sprintf("%d/%d xp", dip.dude.XP(), dip.dude.NextLevelXP()))
 	stats := dip.dude.GetCalculatedStats()
 	dip.hp.SetText(fmt.Sprintf("%d/%d hp", stats.currentHp, stats.totalHp))
+	dip.morale.SetText(fmt.Sprintf("%s morale (%s)", PaddedIntString(int(dip.dude.Morale()), 4), dip.dude.MoraleString()))
 	dip.strength.SetText(fmt.Sprintf("%s strength", PaddedIntString(stats.strength, 4)))
 	dip.agility.SetText(fmt.Sprintf("%s agility", PaddedIntString(stats.agility, 4)))
 	dip.defense.SetText(fmt.Sprintf("%s defense", PaddedIntString(stats.defense, 4)))
@@ -1158,43 +2472,61 @@ func (dip *DudeInfoPanel) SyncDude() {
 	dip.confidence.SetText(fmt.Sprintf("%s confidence", PaddedIntString(stats.confidence, 4)))
 	dip.luck.SetText(fmt.Sprintf("%s luck", PaddedIntString(stats.luck, 4)))
 
-	if armor, ok := dip.dude.equipped[EquipmentTypeArmor]; ok {
-		dip.armorText.SetText(armor.Name())
-		dip.armorText.textOptions.Color = armor.quality.TextColor()
-		if dip.whichSelect == 1 {
-			dip.equipmentDetails.SetEquipment(armor)
+	dip.skillsTitle.SetText(fmt.Sprintf("Skills (%d)", dip.dude.SkillPoints()))
+	dip.skills = GetProfessionSkills(dip.dude.Profession().Base())
+	for i, txt := range dip.skillTexts {
+		if i >= len(dip.skills) {
+			txt.SetText("")
+			continue
 		}
-	} else {
-		dip.armorText.SetText("no armor")
-		dip.armorText.textOptions.Color = assets.ColorDudeDefense
-		if dip.whichSelect == 1 {
-			dip.equipmentDetails.SetEquipment(nil)
+		sk := dip.skills[i]
+		rank := dip.dude.SkillRank(sk)
+		txt.SetText(fmt.Sprintf("%s %d/%d", sk, rank, sk.MaxRank()))
+		if dip.dude.CanLearnSkill(sk) == nil {
+			txt.textOptions.Color = assets.ColorDudeXP
+		} else if rank > 0 {
+			txt.textOptions.Color = assets.ColorDudeLevel
+		} else {
+			txt.textOptions.Color = assets.ColorHeading
 		}
 	}
-	if weapon, ok := dip.dude.equipped[EquipmentTypeWeapon]; ok {
-		dip.weaponText.SetText(weapon.Name())
-		dip.weaponText.textOptions.Color = weapon.quality.TextColor()
-		if dip.whichSelect == 2 {
-			dip.equipmentDetails.SetEquipment(weapon)
-		}
+
+	behavior := dip.dude.Behavior()
+	dip.behaviorButton.text.SetText(fmt.Sprintf("%s\n%s", behavior, behavior.Description()))
+	dip.beltButton.text.SetText(dip.dude.BeltString())
+	if p := dip.dude.Party(); p < len(dip.parties) {
+		dip.partyButton.text.SetText(dip.parties[p].Name())
 	} else {
-		dip.weaponText.SetText("no weapon")
-		dip.weaponText.textOptions.Color = assets.ColorDudeStrength
-		if dip.whichSelect == 2 {
-			dip.equipmentDetails.SetEquipment(nil)
-		}
+		dip.partyButton.text.SetText(PartyName(p))
 	}
-	if accessory, ok := dip.dude.equipped[EquipmentTypeAccessory]; ok {
-		dip.accessoryText.SetText(accessory.Name())
-		dip.accessoryText.textOptions.Color = accessory.quality.TextColor()
-		if dip.whichSelect == 3 {
-			dip.equipmentDetails.SetEquipment(accessory)
-		}
+	dip.partyButton.text.textOptions.Color = PartyColors[dip.dude.Party()]
+
+	if promoted, ok := dip.dude.Profession().Promotion(); ok && dip.dude.CanPromote() {
+		dip.promoteButton.text.SetText(fmt.Sprintf("Promote to %s\n%dgp", promoted.String(), dip.dude.PromotionCost()))
+		dip.promoteButton.hidden = false
 	} else {
-		dip.accessoryText.SetText("no accessory")
-		dip.accessoryText.textOptions.Color = assets.ColorDudeWisdom
-		if dip.whichSelect == 3 {
-			dip.equipmentDetails.SetEquipment(nil)
+		dip.promoteButton.hidden = true
+	}
+
+	for i, txt := range dip.slotTexts {
+		slot := EquipmentSlot(i)
+		eq, ok := dip.dude.equipped[slot]
+		if ok {
+			txt.SetText(eq.Name())
+			txt.textOptions.Color = eq.TextColor()
+		} else if slot == EquipmentSlotOffHand && dip.dude.OffHandBlocked() {
+			txt.SetText("(two-handed)")
+			txt.textOptions.Color = slot.Color()
+		} else {
+			txt.SetText(slot.Empty())
+			txt.textOptions.Color = slot.Color()
+		}
+		if dip.whichSelect == i+1 {
+			if ok {
+				dip.equipmentDetails.SetEquipment(eq)
+			} else {
+				dip.equipmentDetails.SetEquipment(nil)
+			}
 		}
 	}
 }
@@ -1210,12 +2542,34 @@ func (dip *DudeInfoPanel) Layout(o *UIOptions) {
 	dip.equipmentPanel.SetPosition(dip.panel.X()+dip.panel.Width(), dip.panel.Y())
 	dip.equipmentPanel.Layout(nil, o)
 
+	dip.skillsPanel.SetSize(dip.panel.Width()+16*o.Scale, dip.equipmentPanel.Height())
+	dip.skillsPanel.padding = 6 * o.Scale
+	dip.skillsPanel.spaceBetween = -2 * o.Scale
+	dip.skillsPanel.SetPosition(dip.equipmentPanel.X()+dip.equipmentPanel.Width(), dip.equipmentPanel.Y())
+	dip.skillsPanel.Layout(nil, o)
+
+	dip.beltButton.Layout(nil, o)
+	dip.beltButton.SetSize(dip.skillsPanel.Width(), 48)
+	dip.beltButton.SetPosition(dip.skillsPanel.X(), dip.skillsPanel.Y()+dip.skillsPanel.Height()-4*o.Scale)
+
+	dip.promoteButton.Layout(nil, o)
+	dip.promoteButton.SetSize(dip.skillsPanel.Width(), 48)
+	dip.promoteButton.SetPosition(dip.beltButton.X(), dip.beltButton.Y()+dip.beltButton.Height()-4*o.Scale)
+
+	dip.behaviorButton.Layout(nil, o)
+	dip.behaviorButton.SetSize(dip.equipmentPanel.Width(), 48)
+	dip.behaviorButton.SetPosition(dip.equipmentPanel.X(), dip.equipmentPanel.Y()+dip.equipmentPanel.Height()-4*o.Scale)
+
+	dip.partyButton.Layout(nil, o)
+	dip.partyButton.SetSize(dip.equipmentPanel.Width(), 32)
+	dip.partyButton.SetPosition(dip.behaviorButton.X(), dip.behaviorButton.Y()+dip.behaviorButton.Height()-4*o.Scale)
+
 	dip.equipmentDetails.Layout(o)
 	dip.equipmentDetails.panel.SetSize(128*o.Scale, 96*o.Scale)
 	dip.equipmentDetails.panel.SetPosition(dip.panel.X(), dip.panel.Y()+dip.panel.Height())
 
 	// Dynamically size our equipmentDetails panel.
-	newHeight := (dip.equipmentDetails.luck.Y() + dip.equipmentDetails.luck.Height()) - dip.equipmentDetails.panel.Y()
+	newHeight := (dip.equipmentDetails.comparison.Y() + dip.equipmentDetails.comparison.Height()) - dip.equipmentDetails.panel.Y()
 	newHeight = math.Ceil(newHeight/dip.equipmentDetails.panel.center.Height()) * dip.equipmentDetails.panel.center.Height()
 	dip.equipmentDetails.panel.SetSize(128*o.Scale, newHeight)
 }
@@ -1230,6 +2584,11 @@ func (dip *DudeInfoPanel) Update(o *UIOptions) {
 	}
 	if dip.showDetails {
 		dip.equipmentPanel.Update(o)
+		dip.skillsPanel.Update(o)
+		dip.promoteButton.Update(o)
+		dip.behaviorButton.Update(o)
+		dip.beltButton.Update(o)
+		dip.partyButton.Update(o)
 		dip.equipmentDetails.Update(o)
 	}
 }
@@ -1245,6 +2604,21 @@ func (dip *DudeInfoPanel) Check(mx, my float64, kind UICheckKind) bool {
 		if dip.equipmentPanel.Check(mx, my, kind) {
 			return true
 		}
+		if dip.onPromoteClick != nil && dip.promoteButton.Check(mx, my, kind) {
+			return true
+		}
+		if dip.behaviorButton.Check(mx, my, kind) {
+			return true
+		}
+		if dip.beltButton.Check(mx, my, kind) {
+			return true
+		}
+		if dip.onPartyClick != nil && dip.partyButton.Check(mx, my, kind) {
+			return true
+		}
+		if dip.skillsPanel.Check(mx, my, kind) {
+			return true
+		}
 		if dip.equipmentDetails.Check(mx, my, kind) {
 			return true
 		}
@@ -1259,19 +2633,21 @@ func (dip *DudeInfoPanel) Draw(o *render.Options) {
 	dip.panel.Draw(o)
 	if dip.showDetails {
 		dip.equipmentPanel.Draw(o)
+		dip.skillsPanel.Draw(o)
+		if dip.onPromoteClick != nil {
+			dip.promoteButton.Draw(o)
+		}
+		dip.behaviorButton.Draw(o)
+		dip.beltButton.Draw(o)
+		if dip.onPartyClick != nil {
+			dip.partyButton.Draw(o)
+		}
 		// Time to be hackie :)
-		if dip.whichSelect == 1 {
-			x, y := dip.armorText.Position()
-			w, h := dip.armorText.Size()
-			vector.StrokeRect(o.Screen, float32(x), float32(y), float32(w), float32(h), 1, assets.ColorDudeDefense, false)
-		} else if dip.whichSelect == 2 {
-			x, y := dip.weaponText.Position()
-			w, h := dip.weaponText.Size()
-			vector.StrokeRect(o.Screen, float32(x), float32(y), float32(w), float32(h), 1, assets.ColorDudeStrength, false)
-		} else if dip.whichSelect == 3 {
-			x, y := dip.accessoryText.Position()
-			w, h := dip.accessoryText.Size()
-			vector.StrokeRect(o.Screen, float32(x), float32(y), float32(w), float32(h), 1, assets.ColorDudeWisdom, false)
+		if dip.whichSelect > 0 && dip.whichSelect <= len(dip.slotTexts) {
+			txt := dip.slotTexts[dip.whichSelect-1]
+			x, y := txt.Position()
+			w, h := txt.Size()
+			vector.StrokeRect(o.Screen, float32(x), float32(y), float32(w), float32(h), 1, EquipmentSlot(dip.whichSelect-1).Color(), false)
 		}
 		dip.equipmentDetails.Draw(o)
 	}
@@ -1285,6 +2661,9 @@ const (
 	SortPropertyType
 	SortPropertyProfession
 	SortPropertyName
+	SortPropertyPerks
+	// Marker for the end... allows for iteration
+	SortPropertyEnd
 )
 
 func (sp SortProperty) String() string {
@@ -1297,6 +2676,8 @@ func (sp SortProperty) String() string {
 		return "Profession"
 	case SortPropertyName:
 		return "Name"
+	case SortPropertyPerks:
+		return "Perks"
 	default:
 		return "Unknown"
 	}
@@ -1335,7 +2716,7 @@ func MakeEquipmentPanel() EquipmentPanel {
 	buy := MakeButtonPanel(assets.BodyFont, PanelStyleButtonAttached)
 	ep.buyButton = &buy
 	ep.buyButton.text.center = true
-	ep.buyButton.text.SetText("Buy Loot")
+	ep.buyButton.text.SetText("Shop")
 
 	// Sort
 	sort := MakeButtonPanel(assets.BodyFont, PanelStyleButton)
@@ -1368,7 +2749,7 @@ func (ep *EquipmentPanel) SetEquipment(equipment []*Equipment) {
 	ep.equipment = equipment
 	ep.list.Clear()
 	for index, eq := range equipment {
-		txt := NewUIText(eq.Name(), assets.BodyFont, eq.quality.TextColor())
+		txt := NewUIText(eq.Name(), assets.BodyFont, eq.TextColor())
 		txt.ignoreScale = true
 		ep.list.AddItem(txt)
 
@@ -1418,10 +2799,10 @@ func (ep *EquipmentPanel) Layout(o *UIOptions) {
 	ep.details.panel.SetSize(128*o.Scale, 96*o.Scale)
 	ep.details.panel.SetPosition(ep.panel.X()+ep.panel.Width()+4*o.Scale, ep.panel.Y())
 
-	// Dynamically size our details panel.
-	//newHeight := (ep.details.luck.Y() + ep.details.luck.Height()) - ep.details.panel.Y()
-	//newHeight = math.Ceil(newHeight/ep.details.panel.center.Height()) * ep.details.panel.center.Height()
-	//ep.details.panel.SetSize(128*o.Scale, newHeight)
+	// Grow our details panel to fit set bonuses and comparisons.
+	newHeight := (ep.details.comparison.Y() + ep.details.comparison.Height()) - ep.details.panel.Y()
+	newHeight = math.Ceil(newHeight/ep.details.panel.center.Height()) * ep.details.panel.center.Height()
+	ep.details.panel.SetSize(128*o.Scale, math.Max(96*o.Scale, newHeight))
 }
 
 func (ep *EquipmentPanel) Update(o *UIOptions) {
@@ -1443,7 +2824,7 @@ func (ep *EquipmentPanel) Check(mx, my float64, kind UICheckKind) bool {
 	}
 	if ep.sortButton.Check(mx, my, kind) {
 		if kind == UICheckClick {
-			ep.sortMethod = (ep.sortMethod + 1) % 4
+			ep.sortMethod = (ep.sortMethod + 1) % SortPropertyEnd
 			if ep.onSortClick != nil {
 				ep.onSortClick(ep.sortMethod)
 			}
@@ -1508,6 +2889,11 @@ type EquipmentDetailsPanel struct {
 	wisdom     *UIText
 	confidence *UIText
 	luck       *UIText
+	set        *UIText
+	owner      *Dude // Dude whose equipped set pieces are counted, if any.
+	comparison *UIText
+	compare    *Dude   // Dude to compare the equipment against, if any.
+	roster     []*Dude // Dudes to suggest the equipment for when comparing.
 
 	swapButton  *ButtonPanel
 	sellButton  *ButtonPanel
@@ -1532,6 +2918,8 @@ func NewEquipmentDetailsPanel(small bool) *EquipmentDetailsPanel {
 		wisdom:          NewUIText("", assets.BodyFont, assets.ColorDudeWisdom),
 		confidence:      NewUIText("", assets.BodyFont, assets.ColorDudeConfidence),
 		luck:            NewUIText("", assets.BodyFont, assets.ColorDudeLuck),
+		set:             NewUIText("", assets.BodyFont, assets.ColorItemSet),
+		comparison:      NewUIText("", assets.BodyFont, assets.ColorItemCompare),
 		small:           small,
 	}
 	{
@@ -1571,6 +2959,8 @@ func NewEquipmentDetailsPanel(small bool) *EquipmentDetailsPanel {
 	edp.wisdom.ignoreScale = true
 	edp.confidence.ignoreScale = true
 	edp.luck.ignoreScale = true
+	edp.set.ignoreScale = true
+	edp.comparison.ignoreScale = true
 	edp.panel.AddChild(edp.title)
 	edp.panel.AddChild(edp.level)
 	edp.panel.AddChild(edp.perk)
@@ -1583,6 +2973,8 @@ func NewEquipmentDetailsPanel(small bool) *EquipmentDetailsPanel {
 	edp.panel.AddChild(edp.wisdom)
 	edp.panel.AddChild(edp.confidence)
 	edp.panel.AddChild(edp.luck)
+	edp.panel.AddChild(edp.set)
+	edp.panel.AddChild(edp.comparison)
 	edp.panel.sizeChildren = true
 	return edp
 }
@@ -1591,7 +2983,7 @@ func (edp *EquipmentDetailsPanel) SetEquipment(equipment *Equipment) {
 	edp.equipment = equipment
 	if equipment != nil {
 		edp.title.SetText(equipment.Name())
-		edp.title.textOptions.Color = equipment.quality.TextColor()
+		edp.title.textOptions.Color = equipment.TextColor()
 		edp.description.SetText(equipment.Description())
 		professions := equipment.professions
 		professionText := ""
@@ -1605,17 +2997,32 @@ func (edp *EquipmentDetailsPanel) SetEquipment(equipment *Equipment) {
 			professionText += " "
 		}
 
-		edp.level.SetText(fmt.Sprintf("Level %d %s%s", equipment.stats.level, professionText, equipment.Type()))
-
-		edp.uses.SetText(fmt.Sprintf("%d/%d uses", equipment.uses, equipment.totalUses))
-
-		if equipment.perk != nil {
-			edp.perk.SetText(equipment.perk.Name())
-			edp.perk.textOptions.Color = equipment.perk.Quality().TextColor()
-			edp.perkDescription.SetText(equipment.perk.Description())
-		} else {
-			edp.perk.SetText("")
-			edp.perkDescription.SetText("")
+		if equipment.IsUnique() {
+			professionText = "Unique " + professionText
+		}
+		edp.level.SetText(fmt.Sprintf("Level %d %s%s", equipment.stats.level, professionText, equipment.SlotString()))
+
+		sockets := len(equipment.Sockets()) + equipment.FreeSockets()
+		edp.uses.SetText(fmt.Sprintf("%d/%d sockets filled", len(equipment.Sockets()), sockets))
+
+		// Each perk is listed with its own uses, colored by the best of them.
+		var perks, descriptions []string
+		best := PerkQualityTrash
+		for _, ps := range equipment.Sockets() {
+			perks = append(perks, fmt.Sprintf("%s (%s)", strings.TrimSpace(ps.perk.Name()), ps.UsesString()))
+			descriptions = append(descriptions, ps.perk.Description())
+			best = max(best, ps.perk.Quality())
+		}
+		edp.perk.SetText(strings.Join(perks, "\n"))
+		edp.perk.textOptions.Color = best.TextColor()
+		edp.perkDescription.SetText(strings.Join(descriptions, "\n"))
+
+		// Consumables have no level or sockets, just what they do.
+		if equipment.IsConsumable() {
+			edp.level.SetText(equipment.SlotString())
+			edp.uses.SetText("")
+			edp.perk.SetText(equipment.consumable.Description())
+			edp.perk.textOptions.Color = assets.ColorItemPerk
 		}
 
 		edp.agility.SetText(fmt.Sprintf("%s agility", PaddedIntString(equipment.stats.agility, 4)))
@@ -1624,6 +3031,8 @@ func (edp *EquipmentDetailsPanel) SetEquipment(equipment *Equipment) {
 		edp.wisdom.SetText(fmt.Sprintf("%s wisdom", PaddedIntString(equipment.stats.wisdom, 4)))
 		edp.confidence.SetText(fmt.Sprintf("%s confidence", PaddedIntString(equipment.stats.confidence, 4)))
 		edp.luck.SetText(fmt.Sprintf("%s luck", PaddedIntString(equipment.stats.luck, 4)))
+		edp.set.SetText(equipment.SetsDescription(edp.owner))
+		edp.syncComparison()
 
 		edp.sellButton.text.SetText(fmt.Sprintf("Sell for\n%dgp", equipment.GoldValue()))
 
@@ -1633,6 +3042,69 @@ func (edp *EquipmentDetailsPanel) SetEquipment(equipment *Equipment) {
 	}
 }
 
+// SetComparison compares the shown equipment against what the dude has on, suggesting the best fits from the roster. A nil dude only suggests.
+func (edp *EquipmentDetailsPanel) SetComparison(d *Dude, roster []*Dude) {
+	edp.compare = d
+	edp.roster = roster
+	if edp.equipment != nil {
+		edp.SetEquipment(edp.equipment)
+	}
+}
+
+// syncComparison colors the stat lines by how they'd change the compared dude's stats and describes the rest of the comparison.
+func (edp *EquipmentDetailsPanel) syncComparison() {
+	lines := []struct {
+		text  *UIText
+		color color.Color
+		delta func(s *Stats) int
+	}{
+		{edp.agility, assets.ColorDudeAgility, func(s *Stats) int { return s.agility }},
+		{edp.strength, assets.ColorDudeStrength, func(s *Stats) int { return s.strength }},
+		{edp.defense, assets.ColorDudeDefense, func(s *Stats) int { return s.defense }},
+		{edp.wisdom, assets.ColorDudeWisdom, func(s *Stats) int { return s.wisdom }},
+		{edp.confidence, assets.ColorDudeConfidence, func(s *Stats) int { return s.confidence }},
+		{edp.luck, assets.ColorDudeLuck, func(s *Stats) int { return s.luck }},
+	}
+	if edp.compare == nil || edp.equipment.IsConsumable() {
+		for _, l := range lines {
+			l.text.textOptions.Color = l.color
+		}
+		if edp.compare != nil {
+			edp.comparison.SetText(fmt.Sprintf("%s's %s", edp.compare.Name(), strings.ReplaceAll(edp.compare.BeltString(), "\n", ": ")))
+		} else if len(edp.roster) > 0 && !edp.equipment.IsConsumable() {
+			edp.comparison.SetText(BestDudesString(edp.equipment, edp.roster))
+		} else {
+			edp.comparison.SetText("")
+		}
+		return
+	}
+
+	c := CompareEquipment(edp.compare, edp.equipment)
+	delta := c.Delta()
+	for _, l := range lines {
+		amount := l.delta(delta)
+		switch {
+		case !c.canEquip || amount == 0:
+			l.text.textOptions.Color = l.color
+		case amount > 0:
+			l.text.textOptions.Color = assets.ColorStatUp
+		default:
+			l.text.textOptions.Color = assets.ColorStatDown
+		}
+		if c.canEquip && amount != 0 {
+			l.text.SetText(fmt.Sprintf("%s (%+d)", l.text.text, amount))
+		}
+	}
+	text := c.String()
+	if c.canEquip && delta.totalHp != 0 {
+		text += fmt.Sprintf("\n%+d max hp", delta.totalHp)
+	}
+	if len(edp.roster) > 0 {
+		text += "\n" + BestDudesString(edp.equipment, edp.roster)
+	}
+	edp.comparison.SetText(text)
+}
+
 func (edp *EquipmentDetailsPanel) Layout(o *UIOptions) {
 	if edp.hidden {
 		return
@@ -0,0 +1,307 @@
+package game
+
+import (
+	"fmt"
+	"image/color"
+	"math/rand"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/kettek/ebijam24/assets"
+	"github.com/kettek/ebijam24/internal/render"
+)
+
+const (
+	UniqueBossChance = 0.15 // Chance a boss drops a unique.
+	UniqueDeepChance = 0.03 // Chance room loot is a unique, once deep enough for it.
+)
+
+// UniqueItem is a hand-made legendary from the unique catalog.
+type UniqueItem struct {
+	name        string
+	base        string
+	description string
+	stats       *Stats // Stats per level, replacing those of the base.
+	perk        string
+	perkQuality PerkQuality
+	stack       string
+	tint        *ebiten.ColorScale
+	minStory    int
+	boss        bool // only dropped by its boss
+}
+
+var uniqueItems []*UniqueItem
+
+// uniqueDropped is set once a unique has dropped this run, only one is found per run.
+var uniqueDropped bool
+
+// uniquesOwned holds the names of the uniques the player has this run, these never drop again.
+var uniquesOwned = make(map[string]bool)
+
+// LoadUniqueItems loads the unique catalog from assets, must be called after the equipment is loaded.
+func LoadUniqueItems() {
+	assets.LoadUniqueEquipment()
+
+	uniqueItems = nil
+	for _, a := range assets.GetUniqueEquipment() {
+		u := &UniqueItem{
+			name:        a.Name,
+			base:        a.Base,
+			description: a.Description,
+			stats: &Stats{
+				totalHp:    a.Stats["totalHp"],
+				strength:   a.Stats["strength"],
+				wisdom:     a.Stats["wisdom"],
+				defense:    a.Stats["defense"],
+				agility:    a.Stats["agility"],
+				confidence: a.Stats["confidence"],
+				luck:       a.Stats["luck"],
+			},
+			perk:        a.Perk,
+			perkQuality: PerkQuality(a.PerkQuality),
+			stack:       a.Stack,
+			minStory:    a.MinStory,
+			boss:        a.Boss,
+		}
+		if a.Tint != "" {
+			var r, g, b uint8
+			if _, err := fmt.Sscanf(a.Tint, "#%02x%02x%02x", &r, &g, &b); err != nil {
+				fmt.Println("Bad tint for unique equipment", a.Name, a.Tint)
+			} else {
+				u.tint = &ebiten.ColorScale{}
+				u.tint.Scale(float32(r)/255, float32(g)/255, float32(b)/255, 1)
+			}
+		}
+		if GetPerkByName(u.perk, "", u.perkQuality) == nil {
+			fmt.Println("Unknown perk for unique equipment", a.Name, a.Perk)
+		}
+		uniqueItems = append(uniqueItems, u)
+	}
+}
+
+// GetUniqueItem returns the unique with the name, or nil if there is none.
+func GetUniqueItem(name string) *UniqueItem {
+	for _, u := range uniqueItems {
+		if u.name == name {
+			return u
+		}
+	}
+	return nil
+}
+
+// Name returns the unique's name.
+func (u *UniqueItem) Name() string {
+	return u.name
+}
+
+// Equipment creates the unique at the given level.
+func (u *UniqueItem) Equipment(level int) *Equipment {
+	e := NewEquipment(u.base, 0, EquipmentQualityLegendary, nil)
+	if e == nil {
+		return nil
+	}
+	u.Apply(e)
+	e.sockets = nil
+	e.Socket(GetPerkByName(u.perk, "", u.perkQuality))
+	for i := 0; i < level; i++ {
+		e.LevelUp(EquipmentQualityLegendary)
+	}
+	return e
+}
+
+// Drop creates the unique at the given level and announces it, unless a unique already dropped this run or the player owns it. Returns nil if it didn't drop.
+func (u *UniqueItem) Drop(level int) *Equipment {
+	if uniqueDropped || uniquesOwned[u.name] {
+		return nil
+	}
+	e := u.Equipment(level)
+	if e != nil {
+		uniqueDropped = true
+		uniquesOwned[u.name] = true
+		AddMessage(
+			MessageLoot,
+			fmt.Sprintf("%s has surfaced in the tower!", e.DisplayName()),
+		)
+	}
+	return e
+}
+
+// Apply turns the equipment into the unique, keeping its level and perks.
+func (u *UniqueItem) Apply(e *Equipment) {
+	e.unique = u
+	e.uniqueName = u.name
+	e.description = u.description
+	levelUpChange := *u.stats
+	e.stats.levelUpChange = &levelUpChange
+	if u.stack != "" {
+		if base, err := assets.GetEquipment(u.base); err == nil {
+			sheet := base.Type
+			if base.Sheet != "" {
+				sheet = base.Sheet
+			}
+			if stack, err := render.NewStack(fmt.Sprintf("equipment/%s", sheet), u.stack, ""); err == nil {
+				e.stack = stack
+			} else {
+				fmt.Println("Error loading stack for unique equipment", u.name, err)
+			}
+		}
+	}
+}
+
+// IsUnique returns if the equipment is from the unique catalog.
+func (e *Equipment) IsUnique() bool {
+	return e.unique != nil
+}
+
+// TextColor returns the color to write the equipment's name in, uniques stand out from their quality.
+func (e *Equipment) TextColor() color.Color {
+	if e.IsUnique() {
+		return assets.ColorItemUnique
+	}
+	return e.quality.TextColor()
+}
+
+// ResetUniqueDrops lets another unique drop, called as a run begins with the equipment the player starts with.
+func ResetUniqueDrops(owned []*Equipment) {
+	uniqueDropped = false
+	uniquesOwned = make(map[string]bool)
+	for _, e := range owned {
+		if e.IsUnique() {
+			uniquesOwned[e.unique.name] = true
+		}
+	}
+}
+
+// RollUnique rolls for a unique, from bosses or the deep stories. Returns nil if none dropped or one already has this run, owned uniques are skipped. Boss uniques are left to their bosses' loot tables.
+func RollUnique(storyLevel int, boss bool) *Equipment {
+	if uniqueDropped {
+		return nil
+	}
+	var candidates []*UniqueItem
+	for _, u := range uniqueItems {
+		if u.boss || uniquesOwned[u.name] {
+			continue
+		}
+		if boss || storyLevel >= u.minStory {
+			candidates = append(candidates, u)
+		}
+	}
+	chance := UniqueDeepChance
+	if boss {
+		chance = UniqueBossChance
+	}
+	if len(candidates) == 0 || rand.Float64() >= chance {
+		return nil
+	}
+	return candidates[rand.Intn(len(candidates))].Drop(storyLevel)
+}
+
+// signaturePerks returns one of every perk reserved for uniques, sharing the given base perk. These are never rolled.
+func signaturePerks(perk *Perk) []IPerk {
+	return []IPerk{
+		PerkUndying{perk},
+		PerkMidasTouch{perk},
+		PerkRallyingCry{perk},
+	}
+}
+
+// PerkUndying keeps the dude standing through a killing blow.
+type PerkUndying struct {
+	*Perk
+}
+
+func (p PerkUndying) amount() int {
+	return int(p.quality+1) * 3
+}
+
+func (p PerkUndying) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkUndying) String() string {
+	return "Undying"
+}
+
+func (p PerkUndying) Description() string {
+	return fmt.Sprintf("Survives a killing blow with %d hp", p.amount())
+}
+
+func (p PerkUndying) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventDudeHit:
+		if e.dude.IsDead() {
+			e.dude.stats.currentHp = p.amount()
+			e.dude.dirtyStats = true
+			e.dude.floatingText("*undying*", color.NRGBA{255, 230, 128, 255}, 60, 0.5)
+			AddMessage(
+				MessageGood,
+				fmt.Sprintf("%s refused to die!", e.dude.name),
+			)
+			return true
+		}
+	}
+	return false
+}
+
+// PerkMidasTouch turns crits into gold.
+type PerkMidasTouch struct {
+	*Perk
+}
+
+func (p PerkMidasTouch) amount(damage int) int {
+	return damage * int(p.quality+1) / 2
+}
+
+func (p PerkMidasTouch) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkMidasTouch) String() string {
+	return "Midas Touch"
+}
+
+func (p PerkMidasTouch) Description() string {
+	return fmt.Sprintf("Crits strike gold, %d%% of the damage", int(p.quality+1)*50)
+}
+
+func (p PerkMidasTouch) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventDudeCrit:
+		if gold := p.amount(e.amount); gold > 0 {
+			e.dude.Trigger(EventGoldGain{dude: e.dude, amount: gold})
+			return true
+		}
+	}
+	return false
+}
+
+// PerkRallyingCry lifts the dude's morale whenever they enter a room.
+type PerkRallyingCry struct {
+	*Perk
+}
+
+func (p PerkRallyingCry) amount() float64 {
+	return float64(p.quality+1) * 3
+}
+
+func (p PerkRallyingCry) Name() string {
+	return constructName(p.String(), p.quality, nil)
+}
+
+func (p PerkRallyingCry) String() string {
+	return "Rallying Cry"
+}
+
+func (p PerkRallyingCry) Description() string {
+	return fmt.Sprintf("Raises morale by %d when entering a room", int(p.amount()))
+}
+
+func (p PerkRallyingCry) Check(e Event) bool {
+	switch e := e.(type) {
+	case EventEnterRoom:
+		if e.dude.Morale() < MoraleMax {
+			e.dude.AdjustMorale(p.amount())
+			return true
+		}
+	}
+	return false
+}
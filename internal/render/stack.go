@@ -79,6 +79,7 @@ func CopyStack(stack *Stack) *Stack {
 		SliceOffset:      stack.SliceOffset,
 		HeightOffset:     stack.HeightOffset,
 		SliceColorMin:    0.5,
+		ColorScale:       stack.ColorScale,
 	}
 }
 
@@ -192,6 +193,11 @@ func (s *Stack) SetStack(name string) error {
 	return s.SetAnimation(s.currentAnimation.Name)
 }
 
+// StackName returns the name of the current stack.
+func (s *Stack) StackName() string {
+	return s.currentStack.Name
+}
+
 func (s *Stack) Stacks() []string {
 	return s.data.StackNames
 }